@@ -1,6 +1,10 @@
 package ast
 
 import (
+	"bytes"
+	"math/big"
+	"strings"
+
 	"github.com/kkirsche/monkey/token"
 )
 
@@ -9,6 +13,9 @@ import (
 // connected to each other, constucting a tree structure
 type Node interface {
 	TokenLiteral() string
+	// String prints the node as source code, which is helpful when debugging
+	// and when comparing parsed trees in tests
+	String() string
 }
 
 // Statement is a specific type of node, and represents each "statement" or
@@ -26,6 +33,14 @@ type Expression interface {
 	expressionNode()
 }
 
+// Pattern is a specific type of node, and represents the target of a binding
+// such as the left hand side of a let statement. A pattern either names a
+// single value, or takes apart an array or hash and binds each of it's parts
+type Pattern interface {
+	Node
+	patternNode()
+}
+
 // Program is the root of our AST
 type Program struct {
 	Statements []Statement
@@ -40,15 +55,28 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+// String implements the Node interface for the Program structure
+func (p *Program) String() string {
+	var out bytes.Buffer
+
+	for _, s := range p.Statements {
+		out.WriteString(s.String())
+	}
+
+	return out.String()
+}
+
 // LetStatement is a node type which handles the `let x = 5 *5` type of
 // binding expression. It's composed of three pieces, the Token, Name and Value
 // which allows us to keep track of the token literal (via Token), the
 // expression which produces the value (Value), and the name of what the
-// expression is being bound to
+// expression is being bound to. The name may also be a destructuring pattern,
+// as in `let [first, ...rest] = xs;`
 type LetStatement struct {
 	Token token.Token // the token.LET token
-	Name  *Identifier // the identifier of the binding
-	Value *Expression // The expression that produces the value
+	Name  Pattern     // the identifier or pattern of the binding
+	Type  *TypeExpr   // the optional type annotation, nil when absent
+	Value Expression  // The expression that produces the value
 }
 
 // statementNode implements the Statement interface
@@ -57,12 +85,30 @@ func (ls *LetStatement) statementNode() {}
 // TokenLiteral implements the Node interface
 func (ls *LetStatement) TokenLiteral() string { return ls.Token.Literal }
 
+// String implements the Node interface
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.String())
+	if ls.Type != nil {
+		out.WriteString(": " + ls.Type.String())
+	}
+	out.WriteString(" = ")
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
 // ReturnStatement is how a function can return a value to it's caller. This
 // follows structure `return <expression>`. Thus consist solely of a keyword,
 // 'return', and the expression
 type ReturnStatement struct {
 	Token       token.Token // the 'return' token
-	ReturnValue *Expression
+	ReturnValue Expression
 }
 
 // statementNode implements the Statement interface
@@ -71,6 +117,241 @@ func (rs *ReturnStatement) statementNode() {}
 // TokenLiteral implements the Node interface
 func (rs *ReturnStatement) TokenLiteral() string { return rs.Token.Literal }
 
+// String implements the Node interface
+func (rs *ReturnStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(rs.TokenLiteral() + " ")
+	if rs.ReturnValue != nil {
+		out.WriteString(rs.ReturnValue.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ImportStatement loads another module and binds it to a name in the
+// importing module. This follows the structure `import "path/to/mod" as m;`
+type ImportStatement struct {
+	Token token.Token    // the 'import' token
+	Path  *StringLiteral // the path of the module being imported
+	Alias *Identifier    // the name the module is bound to
+}
+
+// statementNode implements the Statement interface
+func (is *ImportStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+
+// String implements the Node interface
+func (is *ImportStatement) String() string {
+	return is.TokenLiteral() + " " + is.Path.String() + " as " + is.Alias.String() + ";"
+}
+
+// ExportStatement marks a let binding as visible to the modules which import
+// the module it belongs to, as in `export let x = 5;`
+type ExportStatement struct {
+	Token     token.Token // the 'export' token
+	Statement *LetStatement
+}
+
+// statementNode implements the Statement interface
+func (es *ExportStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (es *ExportStatement) TokenLiteral() string { return es.Token.Literal }
+
+// String implements the Node interface
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Statement.String()
+}
+
+// ExpressionStatement is a statement which consists solely of one expression,
+// such as `x + 10;`. These are legal in Monkey, and are what allow a script to
+// end with a bare value
+type ExpressionStatement struct {
+	Token      token.Token // the first token of the expression
+	Expression Expression
+}
+
+// statementNode implements the Statement interface
+func (es *ExpressionStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (es *ExpressionStatement) TokenLiteral() string { return es.Token.Literal }
+
+// String implements the Node interface
+func (es *ExpressionStatement) String() string {
+	if es.Expression != nil {
+		return es.Expression.String()
+	}
+
+	return ""
+}
+
+// BlockStatement is a sequence of statements surrounded by braces, such as the
+// body of a try statement
+type BlockStatement struct {
+	Token      token.Token // the { token
+	Statements []Statement
+}
+
+// statementNode implements the Statement interface
+func (bs *BlockStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (bs *BlockStatement) TokenLiteral() string { return bs.Token.Literal }
+
+// String implements the Node interface
+func (bs *BlockStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("{ ")
+	for _, s := range bs.Statements {
+		out.WriteString(s.String())
+	}
+	out.WriteString(" }")
+
+	return out.String()
+}
+
+// ThrowStatement raises an error, which unwinds execution until it is caught
+// by an enclosing try statement. This follows the structure
+// `throw <expression>;`
+type ThrowStatement struct {
+	Token token.Token // the 'throw' token
+	Value Expression  // the value being thrown
+}
+
+// statementNode implements the Statement interface
+func (ts *ThrowStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+
+// String implements the Node interface
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// TryStatement runs the Block, and if an error is raised within it, binds the
+// error to the CatchParameter and runs the Catch block. The Finally block is
+// run last whether or not an error was raised. Either of the catch or finally
+// clauses may be left out, but not both, which leaves their fields nil:
+//
+//	try { ... } catch (e) { ... } finally { ... }
+type TryStatement struct {
+	Token          token.Token // the 'try' token
+	Block          *BlockStatement
+	CatchParameter *Identifier // the name the caught error is bound to
+	Catch          *BlockStatement
+	Finally        *BlockStatement
+}
+
+// statementNode implements the Statement interface
+func (ts *TryStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+
+// String implements the Node interface
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	out.WriteString(ts.Block.String())
+	if ts.Catch != nil {
+		out.WriteString(" catch (" + ts.CatchParameter.String() + ") ")
+		out.WriteString(ts.Catch.String())
+	}
+	if ts.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
+
+// WhileStatement runs the Body for as long as the Condition is truthy, which
+// follows the structure `while (<condition>) { ... }`
+type WhileStatement struct {
+	Token     token.Token // the 'while' token
+	Condition Expression
+	Body      *BlockStatement
+}
+
+// statementNode implements the Statement interface
+func (ws *WhileStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (ws *WhileStatement) TokenLiteral() string { return ws.Token.Literal }
+
+// String implements the Node interface
+func (ws *WhileStatement) String() string {
+	return ws.TokenLiteral() + " (" + ws.Condition.String() + ") " + ws.Body.String()
+}
+
+// ForInStatement runs the Body once for each element of the Iterable, with the
+// element bound to the Variable. This follows the structure
+// `for (<identifier> in <expression>) { ... }`. Arrays produce their
+// elements, hashes their keys, strings their characters and integers the
+// range from zero up to, but not including, the integer.
+type ForInStatement struct {
+	Token    token.Token // the 'for' token
+	Variable *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+// statementNode implements the Statement interface
+func (fs *ForInStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (fs *ForInStatement) TokenLiteral() string { return fs.Token.Literal }
+
+// String implements the Node interface
+func (fs *ForInStatement) String() string {
+	return fs.TokenLiteral() + " (" + fs.Variable.String() + " in " + fs.Iterable.String() + ") " + fs.Body.String()
+}
+
+// BreakStatement ends the innermost enclosing loop
+type BreakStatement struct {
+	Token token.Token // the 'break' token
+}
+
+// statementNode implements the Statement interface
+func (bs *BreakStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (bs *BreakStatement) TokenLiteral() string { return bs.Token.Literal }
+
+// String implements the Node interface
+func (bs *BreakStatement) String() string { return bs.TokenLiteral() + ";" }
+
+// ContinueStatement skips the rest of the body of the innermost enclosing
+// loop, moving on to it's next iteration
+type ContinueStatement struct {
+	Token token.Token // the 'continue' token
+}
+
+// statementNode implements the Statement interface
+func (cs *ContinueStatement) statementNode() {}
+
+// TokenLiteral implements the Node interface
+func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
+
+// String implements the Node interface
+func (cs *ContinueStatement) String() string { return cs.TokenLiteral() + ";" }
+
 // Identifier is the individual identifier which represents the expression
 // While not all statements have a value for their identifier, some do, and as
 // such this structure allows us to reuse the identifier for different
@@ -86,3 +367,674 @@ func (i *Identifier) expressionNode() {}
 
 // TokenLiteral implements the Node interface
 func (i *Identifier) TokenLiteral() string { return i.Token.Literal }
+
+// String implements the Node interface
+func (i *Identifier) String() string { return i.Value }
+
+// patternNode implements the Pattern interface, an identifier binds the whole
+// value to it's name
+func (i *Identifier) patternNode() {}
+
+// ArrayPattern binds the elements of an array by their position, such as the
+// `[a, b, ...rest]` in `let [a, b, ...rest] = xs;`. The final element may be a
+// RestElement, which collects any remaining elements into a new array
+type ArrayPattern struct {
+	Token    token.Token // the token.LBRACKET
+	Elements []Pattern
+}
+
+// patternNode implements the Pattern interface
+func (ap *ArrayPattern) patternNode() {}
+
+// TokenLiteral implements the Node interface
+func (ap *ArrayPattern) TokenLiteral() string { return ap.Token.Literal }
+
+// String implements the Node interface
+func (ap *ArrayPattern) String() string {
+	elements := []string{}
+	for _, el := range ap.Elements {
+		elements = append(elements, el.String())
+	}
+
+	return "[" + strings.Join(elements, ", ") + "]"
+}
+
+// HashPattern binds the values of a hash by their keys, such as the
+// `{name, age: years}` in `let {name, age: years} = person;`. The final pair
+// may be replaced by a RestElement, which collects any remaining pairs into a
+// new hash
+type HashPattern struct {
+	Token token.Token // the token.LBRACE
+	Pairs []HashPatternPair
+	Rest  *RestElement // the optional rest element, nil when absent
+}
+
+// HashPatternPair is a single key of a hash pattern, and the pattern which
+// it's value is bound to. In the shorthand form `{name}` the Value is an
+// identifier with the same name as the Key
+type HashPatternPair struct {
+	Key   *Identifier
+	Value Pattern
+}
+
+// patternNode implements the Pattern interface
+func (hp *HashPattern) patternNode() {}
+
+// TokenLiteral implements the Node interface
+func (hp *HashPattern) TokenLiteral() string { return hp.Token.Literal }
+
+// String implements the Node interface
+func (hp *HashPattern) String() string {
+	pairs := []string{}
+	for _, pair := range hp.Pairs {
+		if ident, ok := pair.Value.(*Identifier); ok && ident.Value == pair.Key.Value {
+			pairs = append(pairs, pair.Key.String())
+			continue
+		}
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+	if hp.Rest != nil {
+		pairs = append(pairs, hp.Rest.String())
+	}
+
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
+
+// RestElement collects whatever is left over once the other parts of an
+// array or hash pattern have been bound, such as the `...rest` in
+// `let [first, ...rest] = xs;`
+type RestElement struct {
+	Token token.Token // the token.SPREAD
+	Name  *Identifier
+}
+
+// patternNode implements the Pattern interface
+func (re *RestElement) patternNode() {}
+
+// TokenLiteral implements the Node interface
+func (re *RestElement) TokenLiteral() string { return re.Token.Literal }
+
+// String implements the Node interface
+func (re *RestElement) String() string { return "..." + re.Name.String() }
+
+// WildcardPattern matches any value without binding it, and is written as a
+// single underscore
+type WildcardPattern struct {
+	Token token.Token // the token.IDENT of the underscore
+}
+
+// patternNode implements the Pattern interface
+func (wp *WildcardPattern) patternNode() {}
+
+// TokenLiteral implements the Node interface
+func (wp *WildcardPattern) TokenLiteral() string { return wp.Token.Literal }
+
+// String implements the Node interface
+func (wp *WildcardPattern) String() string { return wp.Token.Literal }
+
+// LiteralPattern matches only a value equal to the literal, such as the `0`
+// in `match (n) { 0 => "zero", _ => "other" }`. The Value is an integer,
+// float, string, boolean or null literal, or a negated number
+type LiteralPattern struct {
+	Token token.Token // the first token of the literal
+	Value Expression
+}
+
+// patternNode implements the Pattern interface
+func (lp *LiteralPattern) patternNode() {}
+
+// TokenLiteral implements the Node interface
+func (lp *LiteralPattern) TokenLiteral() string { return lp.Token.Literal }
+
+// String implements the Node interface
+func (lp *LiteralPattern) String() string { return lp.Value.String() }
+
+// Bindings returns every identifier which is bound by the pattern, in the
+// order they are written
+func Bindings(p Pattern) []*Identifier {
+	switch p := p.(type) {
+	case *Identifier:
+		return []*Identifier{p}
+	case *RestElement:
+		return []*Identifier{p.Name}
+	case *ArrayPattern:
+		var names []*Identifier
+		for _, el := range p.Elements {
+			names = append(names, Bindings(el)...)
+		}
+		return names
+	case *HashPattern:
+		var names []*Identifier
+		for _, pair := range p.Pairs {
+			names = append(names, Bindings(pair.Value)...)
+		}
+		if p.Rest != nil {
+			names = append(names, p.Rest.Name)
+		}
+		return names
+	}
+
+	return nil
+}
+
+// IntegerLiteral is an integer written directly in the source code, such as
+// the `5` in `let x = 5;`. Value holds the decoded value whichever base the
+// literal was written in. Integers are arbitrary precision, so a literal which
+// is too large for an int64 is held in Big instead, and Value is left as zero.
+// Only literals are covered so far. Arithmetic which promotes to big.Int on
+// overflow, and demotes back once the result fits, belongs to the integer
+// object of the runtime, which Monkey does not have yet
+type IntegerLiteral struct {
+	Token token.Token // the token.INT
+	Value int64
+	Big   *big.Int // the value of the literal when it does not fit in Value
+}
+
+// expressionNode implements the Expression interface
+func (il *IntegerLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
+
+// String implements the Node interface
+func (il *IntegerLiteral) String() string { return il.Token.Literal }
+
+// FloatLiteral is a floating point number written directly in the source
+// code, such as the `3.14` in `let pi = 3.14;`
+type FloatLiteral struct {
+	Token token.Token // the token.FLOAT
+	Value float64
+}
+
+// expressionNode implements the Expression interface
+func (fl *FloatLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+
+// String implements the Node interface
+func (fl *FloatLiteral) String() string { return fl.Token.Literal }
+
+// StringLiteral is a double quoted string written directly in the source
+// code, such as the `"hello"` in `let greeting = "hello";`
+type StringLiteral struct {
+	Token token.Token // the token.STRING
+	Value string
+}
+
+// expressionNode implements the Expression interface
+func (sl *StringLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
+
+// String implements the Node interface. The value is wrapped in double
+// quotes, so that a string can be told apart from an identifier with the same
+// text. String literals have no escape sequences, so the value is printed as
+// it was written
+func (sl *StringLiteral) String() string { return "\"" + sl.Value + "\"" }
+
+// Boolean is either of the `true` or `false` keywords used as a value
+type Boolean struct {
+	Token token.Token // the token.TRUE or token.FALSE
+	Value bool
+}
+
+// expressionNode implements the Expression interface
+func (b *Boolean) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (b *Boolean) TokenLiteral() string { return b.Token.Literal }
+
+// String implements the Node interface
+func (b *Boolean) String() string { return b.Token.Literal }
+
+// NullLiteral is the `null` keyword used as a value, which represents the
+// absence of any other value
+type NullLiteral struct {
+	Token token.Token // the token.NULL
+}
+
+// expressionNode implements the Expression interface
+func (nl *NullLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (nl *NullLiteral) TokenLiteral() string { return nl.Token.Literal }
+
+// String implements the Node interface
+func (nl *NullLiteral) String() string { return nl.Token.Literal }
+
+// PrefixExpression is an operator applied to the expression to it's right,
+// such as `!ok` or `-5`
+type PrefixExpression struct {
+	Token    token.Token // the prefix token, e.g. !
+	Operator string
+	Right    Expression
+}
+
+// expressionNode implements the Expression interface
+func (pe *PrefixExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (pe *PrefixExpression) TokenLiteral() string { return pe.Token.Literal }
+
+// String implements the Node interface
+func (pe *PrefixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Operator)
+	out.WriteString(pe.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// InfixExpression is a binary operator which sits between two expressions,
+// such as `5 + 5` or `x <= y`. Both operands are always evaluated
+type InfixExpression struct {
+	Token    token.Token // the operator token, e.g. +
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+// expressionNode implements the Expression interface
+func (ie *InfixExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (ie *InfixExpression) TokenLiteral() string { return ie.Token.Literal }
+
+// String implements the Node interface
+func (ie *InfixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	out.WriteString(" " + ie.Operator + " ")
+	out.WriteString(ie.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// LogicalExpression is one of the short-circuiting operators `&&`, `||` and
+// `??`. These look like infix expressions, but are kept as their own node type
+// as the right hand side is only evaluated when the left hand side does not
+// already decide the result. For `??` that is when the left hand side is null
+type LogicalExpression struct {
+	Token    token.Token // the token.AND, token.OR or token.NULLISH
+	Left     Expression
+	Operator string
+	Right    Expression
+}
+
+// expressionNode implements the Expression interface
+func (le *LogicalExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (le *LogicalExpression) TokenLiteral() string { return le.Token.Literal }
+
+// String implements the Node interface
+func (le *LogicalExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(le.Left.String())
+	out.WriteString(" " + le.Operator + " ")
+	out.WriteString(le.Right.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// ArrayLiteral is a list of expressions surrounded by brackets, such as
+// `[1, 2 * 2, x]`. Each element is an expression of it's own
+type ArrayLiteral struct {
+	Token    token.Token // the token.LBRACKET
+	Elements []Expression
+}
+
+// expressionNode implements the Expression interface
+func (al *ArrayLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (al *ArrayLiteral) TokenLiteral() string { return al.Token.Literal }
+
+// String implements the Node interface
+func (al *ArrayLiteral) String() string {
+	var out bytes.Buffer
+
+	elements := []string{}
+	for _, el := range al.Elements {
+		elements = append(elements, el.String())
+	}
+
+	out.WriteString("[")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString("]")
+
+	return out.String()
+}
+
+// IndexExpression retrieves a single element from the expression on it's
+// left, such as `arr[0]`. Both the indexed expression and the index itself
+// may be any expression, as in `getList()[i + 1]`. An Optional index, written
+// as `arr?.[0]`, ends the enclosing OptionalChain with null when the left is
+// null. Indexing a hash with a key it does not contain also results in null,
+// rather than an error, so that `config["port"] ?? 80` supplies a default.
+// Both are runtime behaviour, which is not implemented until Monkey has an
+// evaluator
+type IndexExpression struct {
+	Token    token.Token // the token.LBRACKET or token.QUESTION_DOT
+	Left     Expression
+	Index    Expression
+	Optional bool
+}
+
+// expressionNode implements the Expression interface
+func (ie *IndexExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (ie *IndexExpression) TokenLiteral() string { return ie.Token.Literal }
+
+// String implements the Node interface
+func (ie *IndexExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ie.Left.String())
+	if ie.Optional {
+		out.WriteString("?.")
+	}
+	out.WriteString("[")
+	out.WriteString(ie.Index.String())
+	out.WriteString("])")
+
+	return out.String()
+}
+
+// FieldExpression retrieves a single named field from the expression on it's
+// left, such as `person.name`. An Optional field, written as `person?.name`,
+// ends the enclosing OptionalChain with null when the object is null
+type FieldExpression struct {
+	Token    token.Token // the token.DOT or token.QUESTION_DOT
+	Object   Expression
+	Field    *Identifier
+	Optional bool
+}
+
+// expressionNode implements the Expression interface
+func (fe *FieldExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (fe *FieldExpression) TokenLiteral() string { return fe.Token.Literal }
+
+// String implements the Node interface
+func (fe *FieldExpression) String() string {
+	if fe.Optional {
+		return "(" + fe.Object.String() + "?." + fe.Field.String() + ")"
+	}
+	return "(" + fe.Object.String() + "." + fe.Field.String() + ")"
+}
+
+// OptionalChain marks the extent of a chain of field accesses, index
+// accesses and calls which contains at least one optional link, such as the
+// whole of `config?.server.port`. When an optional link finds null on it's
+// left, the rest of the chain is skipped and the whole chain results in null,
+// so `.port` is never applied to a missing server. The chain ends at the first
+// operator which is not a field access, index access or call, so
+// `(config?.server).port` does fail when there is no server
+type OptionalChain struct {
+	Token      token.Token // the first token.QUESTION_DOT of the chain
+	Expression Expression
+}
+
+// expressionNode implements the Expression interface
+func (oc *OptionalChain) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (oc *OptionalChain) TokenLiteral() string { return oc.Token.Literal }
+
+// String implements the Node interface, the chain itself prints nothing of
+// it's own as it's extent is implied by the syntax
+func (oc *OptionalChain) String() string { return oc.Expression.String() }
+
+// AssignExpression updates an existing binding with a new value, such as
+// `x = 5`, or with the result of an operation on it's current value, such as
+// `x += 1`. The Target is always an *Identifier, *IndexExpression or
+// *FieldExpression, and the value of the whole expression is the assigned
+// value, so that assignments may be chained as in `a = b = 0`
+type AssignExpression struct {
+	Token    token.Token // the token.ASSIGN or compound assignment token
+	Target   Expression
+	Operator string
+	Value    Expression
+}
+
+// expressionNode implements the Expression interface
+func (ae *AssignExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (ae *AssignExpression) TokenLiteral() string { return ae.Token.Literal }
+
+// String implements the Node interface
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+	out.WriteString(ae.Value.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// MatchExpression compares the Subject against the pattern of each arm in
+// turn, and results in the body of the first arm which matches. This follows
+// the structure `match (<expression>) { <pattern> => <expression>, ... }`
+type MatchExpression struct {
+	Token   token.Token // the 'match' token
+	Subject Expression
+	Arms    []MatchArm
+}
+
+// MatchArm is a single arm of a match expression. The arm only matches when
+// the Pattern matches and the optional Guard, written as in
+// `n if n > 0 => n`, is truthy. Any names bound by the pattern are available
+// to both the guard and the body
+type MatchArm struct {
+	Pattern Pattern
+	Guard   Expression // the optional guard, nil when absent
+	Body    Expression
+}
+
+// expressionNode implements the Expression interface
+func (me *MatchExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (me *MatchExpression) TokenLiteral() string { return me.Token.Literal }
+
+// String implements the Node interface
+func (me *MatchExpression) String() string {
+	arms := []string{}
+	for _, arm := range me.Arms {
+		s := arm.Pattern.String()
+		if arm.Guard != nil {
+			s += " if " + arm.Guard.String()
+		}
+		arms = append(arms, s+" => "+arm.Body.String())
+	}
+
+	return me.TokenLiteral() + " (" + me.Subject.String() + ") { " + strings.Join(arms, ", ") + " }"
+}
+
+// HashLiteral is a list of key and value pairs surrounded by braces, such as
+// `{"name": "x", 1: true}`. The pairs are kept in the order they were written
+// so that the order of insertion is preserved
+type HashLiteral struct {
+	Token token.Token // the token.LBRACE
+	Pairs []HashPair
+}
+
+// HashPair is a single key and value pair of a hash literal
+type HashPair struct {
+	Key   Expression
+	Value Expression
+}
+
+// expressionNode implements the Expression interface
+func (hl *HashLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (hl *HashLiteral) TokenLiteral() string { return hl.Token.Literal }
+
+// String implements the Node interface
+func (hl *HashLiteral) String() string {
+	var out bytes.Buffer
+
+	pairs := []string{}
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+": "+pair.Value.String())
+	}
+
+	out.WriteString("{")
+	out.WriteString(strings.Join(pairs, ", "))
+	out.WriteString("}")
+
+	return out.String()
+}
+
+// CallExpression calls the expression on it's left with a list of arguments,
+// such as `add(1, 2)` or `quote(x + y)`
+type CallExpression struct {
+	Token     token.Token // the '(' token
+	Function  Expression  // the expression being called
+	Arguments []Expression
+}
+
+// expressionNode implements the Expression interface
+func (ce *CallExpression) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (ce *CallExpression) TokenLiteral() string { return ce.Token.Literal }
+
+// String implements the Node interface
+func (ce *CallExpression) String() string {
+	var out bytes.Buffer
+
+	args := []string{}
+	for _, a := range ce.Arguments {
+		args = append(args, a.String())
+	}
+
+	out.WriteString(ce.Function.String())
+	out.WriteString("(")
+	out.WriteString(strings.Join(args, ", "))
+	out.WriteString(")")
+
+	return out.String()
+}
+
+// FunctionLiteral defines a function, such as
+// `fn(a: int, b: int) -> int { a + b }`. The parameters and the return value
+// may each carry an optional type annotation
+type FunctionLiteral struct {
+	Token      token.Token // the 'fn' token
+	Parameters []*Parameter
+	ReturnType *TypeExpr // the optional return type annotation, nil when absent
+	Body       *BlockStatement
+}
+
+// Parameter is a single parameter of a function literal, along with it's
+// optional type annotation
+type Parameter struct {
+	Name *Identifier
+	Type *TypeExpr // the optional type annotation, nil when absent
+}
+
+// String prints the parameter as source code
+func (p *Parameter) String() string {
+	if p.Type != nil {
+		return p.Name.String() + ": " + p.Type.String()
+	}
+	return p.Name.String()
+}
+
+// expressionNode implements the Expression interface
+func (fl *FunctionLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (fl *FunctionLiteral) TokenLiteral() string { return fl.Token.Literal }
+
+// String implements the Node interface
+func (fl *FunctionLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range fl.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(fl.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	if fl.ReturnType != nil {
+		out.WriteString("-> " + fl.ReturnType.String() + " ")
+	}
+	out.WriteString(fl.Body.String())
+
+	return out.String()
+}
+
+// MacroLiteral defines a macro, such as `macro(x, y) { quote(...) }`. A macro
+// looks like a function, but it is called before the program is run with the
+// unevaluated syntax of it's arguments, and the syntax it returns replaces the
+// call to the macro in the program
+type MacroLiteral struct {
+	Token      token.Token // the 'macro' token
+	Parameters []*Identifier
+	Body       *BlockStatement
+}
+
+// expressionNode implements the Expression interface
+func (ml *MacroLiteral) expressionNode() {}
+
+// TokenLiteral implements the Node interface
+func (ml *MacroLiteral) TokenLiteral() string { return ml.Token.Literal }
+
+// String implements the Node interface
+func (ml *MacroLiteral) String() string {
+	var out bytes.Buffer
+
+	params := []string{}
+	for _, p := range ml.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString(ml.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") ")
+	out.WriteString(ml.Body.String())
+
+	return out.String()
+}
+
+// TypeExpr is an optional type annotation such as the `int` in
+// `let x: int = 5;` or either of those in `fn(a: int) -> int { a }`. The
+// runtime ignores annotations entirely, they exist so that checkers and
+// documentation tools are able to read the intent of the author from the
+// abstract syntax tree
+type TypeExpr struct {
+	Token token.Token // the token.IDENT naming the type
+	Name  string
+}
+
+// TokenLiteral implements the Node interface
+func (te *TypeExpr) TokenLiteral() string { return te.Token.Literal }
+
+// String implements the Node interface
+func (te *TypeExpr) String() string { return te.Name }
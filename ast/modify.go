@@ -0,0 +1,143 @@
+package ast
+
+// ModifierFunc is called by Modify for each node of the tree, and returns the
+// node which should take it's place
+type ModifierFunc func(Node) Node
+
+// Modify walks the tree rooted at node depth first, replacing each node with
+// the result of calling modifier on it. The children of a node are modified
+// before the node itself. This is what allows a macro call to be substituted
+// with the syntax returned by the macro, wherever in the program it appears.
+//
+// A replacement must be able to take the place of the node it replaces, so a
+// statement must be replaced by a statement and an expression by an
+// expression. Positions which require a specific node type, such as the name
+// of a let statement, are left as nil when replaced by any other type.
+func Modify(node Node, modifier ModifierFunc) Node {
+	switch node := node.(type) {
+	case *Program:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+
+	case *ExpressionStatement:
+		node.Expression = modifyExpression(node.Expression, modifier)
+
+	case *LetStatement:
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *ReturnStatement:
+		node.ReturnValue = modifyExpression(node.ReturnValue, modifier)
+
+	case *ThrowStatement:
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *ExportStatement:
+		node.Statement, _ = Modify(node.Statement, modifier).(*LetStatement)
+
+	case *BlockStatement:
+		for i, statement := range node.Statements {
+			node.Statements[i], _ = Modify(statement, modifier).(Statement)
+		}
+
+	case *TryStatement:
+		node.Block = modifyBlock(node.Block, modifier)
+		node.Catch = modifyBlock(node.Catch, modifier)
+		node.Finally = modifyBlock(node.Finally, modifier)
+
+	case *WhileStatement:
+		node.Condition = modifyExpression(node.Condition, modifier)
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *ForInStatement:
+		node.Iterable = modifyExpression(node.Iterable, modifier)
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *PrefixExpression:
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *InfixExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *LogicalExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Right = modifyExpression(node.Right, modifier)
+
+	case *IndexExpression:
+		node.Left = modifyExpression(node.Left, modifier)
+		node.Index = modifyExpression(node.Index, modifier)
+
+	case *FieldExpression:
+		node.Object = modifyExpression(node.Object, modifier)
+
+	case *OptionalChain:
+		node.Expression = modifyExpression(node.Expression, modifier)
+
+	case *AssignExpression:
+		node.Target = modifyExpression(node.Target, modifier)
+		node.Value = modifyExpression(node.Value, modifier)
+
+	case *MatchExpression:
+		node.Subject = modifyExpression(node.Subject, modifier)
+		for i, arm := range node.Arms {
+			if arm.Guard != nil {
+				node.Arms[i].Guard = modifyExpression(arm.Guard, modifier)
+			}
+			node.Arms[i].Body = modifyExpression(arm.Body, modifier)
+		}
+
+	case *CallExpression:
+		node.Function = modifyExpression(node.Function, modifier)
+		for i, argument := range node.Arguments {
+			node.Arguments[i] = modifyExpression(argument, modifier)
+		}
+
+	case *ArrayLiteral:
+		for i, element := range node.Elements {
+			node.Elements[i] = modifyExpression(element, modifier)
+		}
+
+	case *HashLiteral:
+		for i, pair := range node.Pairs {
+			node.Pairs[i].Key = modifyExpression(pair.Key, modifier)
+			node.Pairs[i].Value = modifyExpression(pair.Value, modifier)
+		}
+
+	case *FunctionLiteral:
+		for _, parameter := range node.Parameters {
+			parameter.Name, _ = Modify(parameter.Name, modifier).(*Identifier)
+		}
+		node.Body = modifyBlock(node.Body, modifier)
+
+	case *MacroLiteral:
+		for i, parameter := range node.Parameters {
+			node.Parameters[i], _ = Modify(parameter, modifier).(*Identifier)
+		}
+		node.Body = modifyBlock(node.Body, modifier)
+	}
+
+	return modifier(node)
+}
+
+// modifyExpression modifies an expression which may be missing, such as the
+// value of a return statement
+func modifyExpression(expression Expression, modifier ModifierFunc) Expression {
+	if expression == nil {
+		return nil
+	}
+
+	modified, _ := Modify(expression, modifier).(Expression)
+	return modified
+}
+
+// modifyBlock modifies a block which may be missing, such as the catch block
+// of a try statement
+func modifyBlock(block *BlockStatement, modifier ModifierFunc) *BlockStatement {
+	if block == nil {
+		return nil
+	}
+
+	modified, _ := Modify(block, modifier).(*BlockStatement)
+	return modified
+}
@@ -0,0 +1,172 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/kkirsche/monkey/token"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModify(t *testing.T) {
+	one := func() Expression { return &IntegerLiteral{Value: 1} }
+	two := func() Expression { return &IntegerLiteral{Value: 2} }
+
+	turnOneIntoTwo := func(node Node) Node {
+		integer, ok := node.(*IntegerLiteral)
+		if !ok || integer.Value != 1 {
+			return node
+		}
+
+		integer.Value = 2
+		return integer
+	}
+
+	block := func(e Expression) *BlockStatement {
+		return &BlockStatement{Statements: []Statement{&ExpressionStatement{Expression: e}}}
+	}
+
+	tests := []struct {
+		input    Node
+		expected Node
+	}{
+		{one(), two()},
+		{
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: one()}}},
+			&Program{Statements: []Statement{&ExpressionStatement{Expression: two()}}},
+		},
+		{
+			&InfixExpression{Left: one(), Operator: "+", Right: two()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&InfixExpression{Left: two(), Operator: "+", Right: one()},
+			&InfixExpression{Left: two(), Operator: "+", Right: two()},
+		},
+		{
+			&LogicalExpression{Left: one(), Operator: "&&", Right: one()},
+			&LogicalExpression{Left: two(), Operator: "&&", Right: two()},
+		},
+		{
+			&PrefixExpression{Operator: "-", Right: one()},
+			&PrefixExpression{Operator: "-", Right: two()},
+		},
+		{
+			&IndexExpression{Left: one(), Index: one()},
+			&IndexExpression{Left: two(), Index: two()},
+		},
+		{
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{one(), two(), one()}},
+			&CallExpression{Function: &Identifier{Value: "f"}, Arguments: []Expression{two(), two(), two()}},
+		},
+		{
+			&ArrayLiteral{Elements: []Expression{one(), one()}},
+			&ArrayLiteral{Elements: []Expression{two(), two()}},
+		},
+		{
+			&HashLiteral{Pairs: []HashPair{{Key: one(), Value: one()}}},
+			&HashLiteral{Pairs: []HashPair{{Key: two(), Value: two()}}},
+		},
+		{
+			&ReturnStatement{ReturnValue: one()},
+			&ReturnStatement{ReturnValue: two()},
+		},
+		{
+			&ReturnStatement{},
+			&ReturnStatement{},
+		},
+		{
+			&LetStatement{Value: one()},
+			&LetStatement{Value: two()},
+		},
+		{
+			&ExportStatement{Statement: &LetStatement{Value: one()}},
+			&ExportStatement{Statement: &LetStatement{Value: two()}},
+		},
+		{
+			&ThrowStatement{Value: one()},
+			&ThrowStatement{Value: two()},
+		},
+		{
+			&TryStatement{Block: block(one()), Catch: block(one()), Finally: block(one())},
+			&TryStatement{Block: block(two()), Catch: block(two()), Finally: block(two())},
+		},
+		{
+			&TryStatement{Block: block(one()), Finally: block(one())},
+			&TryStatement{Block: block(two()), Finally: block(two())},
+		},
+		{
+			&FieldExpression{Object: one(), Field: &Identifier{Value: "x"}},
+			&FieldExpression{Object: two(), Field: &Identifier{Value: "x"}},
+		},
+		{
+			&OptionalChain{Expression: one()},
+			&OptionalChain{Expression: two()},
+		},
+		{
+			&AssignExpression{Target: one(), Operator: "=", Value: one()},
+			&AssignExpression{Target: two(), Operator: "=", Value: two()},
+		},
+		{
+			&MatchExpression{Subject: one(), Arms: []MatchArm{
+				{Pattern: &WildcardPattern{}, Guard: one(), Body: one()},
+				{Pattern: &Identifier{Value: "x"}, Body: one()},
+			}},
+			&MatchExpression{Subject: two(), Arms: []MatchArm{
+				{Pattern: &WildcardPattern{}, Guard: two(), Body: two()},
+				{Pattern: &Identifier{Value: "x"}, Body: two()},
+			}},
+		},
+		{
+			&WhileStatement{Condition: one(), Body: block(one())},
+			&WhileStatement{Condition: two(), Body: block(two())},
+		},
+		{
+			&ForInStatement{Variable: &Identifier{Value: "x"}, Iterable: one(), Body: block(one())},
+			&ForInStatement{Variable: &Identifier{Value: "x"}, Iterable: two(), Body: block(two())},
+		},
+		{
+			&FunctionLiteral{Parameters: []*Parameter{{Name: &Identifier{Value: "x"}}}, Body: block(one())},
+			&FunctionLiteral{Parameters: []*Parameter{{Name: &Identifier{Value: "x"}}}, Body: block(two())},
+		},
+		{
+			&MacroLiteral{Parameters: []*Identifier{}, Body: block(one())},
+			&MacroLiteral{Parameters: []*Identifier{}, Body: block(two())},
+		},
+	}
+
+	for _, tt := range tests {
+		modified := Modify(tt.input, turnOneIntoTwo)
+		assert.Equalf(t, tt.expected, modified, "Invalid modification of %T", tt.input)
+	}
+}
+
+func TestModifyReplacesNodes(t *testing.T) {
+	// replace every call to unless(a, b) with the syntax `!a && b`, just as a
+	// macro expansion would
+	program := &Program{Statements: []Statement{
+		&LetStatement{
+			Token: token.Token{Type: token.LET, Literal: "let"},
+			Name:  &Identifier{Value: "x"},
+			Value: &CallExpression{
+				Function:  &Identifier{Value: "unless"},
+				Arguments: []Expression{&Identifier{Value: "a"}, &Identifier{Value: "b"}},
+			},
+		},
+	}}
+
+	expand := func(node Node) Node {
+		call, ok := node.(*CallExpression)
+		if !ok || call.Function.String() != "unless" {
+			return node
+		}
+
+		return &LogicalExpression{
+			Left:     &PrefixExpression{Operator: "!", Right: call.Arguments[0]},
+			Operator: "&&",
+			Right:    call.Arguments[1],
+		}
+	}
+
+	Modify(program, expand)
+	assert.Equal(t, "let x = ((!a) && b);", program.String())
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/kkirsche/monkey/module"
+	"github.com/kkirsche/monkey/types"
+)
+
+// check implements `monkey check [--types] file...`, which loads each file and
+// the modules it imports, and reports their syntax errors without running
+// them. With --types, the type errors of each named file are reported as well.
+// The exit status of the command is returned
+func check(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	inferTypes := flags.Bool("types", false, "infer types, and report type errors")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: monkey check [--types] file...")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	status := 0
+	resolver := module.NewResolver(module.SearchPath())
+
+	for _, path := range flags.Args() {
+		m, err := resolver.Load(path)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			status = 1
+			continue
+		}
+
+		if !*inferTypes {
+			continue
+		}
+
+		checker := types.New()
+		checker.Check(m.Program)
+		for _, msg := range checker.Errors() {
+			fmt.Fprintf(stderr, "%s: %s\n", m.Path, msg)
+			status = 1
+		}
+	}
+
+	return status
+}
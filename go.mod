@@ -1,5 +1,13 @@
 module github.com/kkirsche/monkey
 
-go 1.12
+go 1.17
 
-require github.com/stretchr/testify v1.3.0
+require (
+	github.com/stretchr/testify v1.3.0
+	golang.org/x/text v0.13.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+)
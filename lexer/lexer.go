@@ -1,30 +1,46 @@
 package lexer
 
 import (
+	"fmt"
+	"io"
 	"unicode"
 	"unicode/utf8"
 
 	"github.com/kkirsche/monkey/token"
+	"golang.org/x/text/unicode/norm"
 )
 
+// chunkSize is the least number of bytes requested from the reader each time
+// the lexer of a stream runs out of buffered input
+const chunkSize = 4096
+
 // Lexer is the structure responsible for converting the input text into a
 // series of tokens
 type Lexer struct {
-	input        string
-	inputLen     int
-	position     int  // current position in input (points to current char)
-	readPosition int  // current reading position in input (after current char)
-	ch           rune // churrent char under examination
-	column       int  // the current number of the column of the line
-	line         int  // the current number of line
+	// input is the entire text when lexing a string. Token literals are
+	// sliced from it, so lexing a string allocates nothing per token
+	input string
+	// window holds the text when lexing a stream, and is nil otherwise. It is
+	// a sliding window over the stream which begins at the start of the
+	// current token. The window is refilled in place, so token literals are
+	// copied out of it
+	window       []byte
+	reader       io.Reader // the stream to refill input from, nil when there is no more input
+	err          error     // the first error returned by the reader, other than io.EOF
+	errors       []string  // descriptions of the ILLEGAL tokens which have been produced
+	mark         int       // the position of the first character of the current token
+	position     int       // current position in input (points to current char)
+	readPosition int       // current reading position in input (after current char)
+	ch           rune      // churrent char under examination
+	column       int       // the current number of the column of the line
+	line         int       // the current number of line
 }
 
 // New is used to create a new lexer instance from the input text
 func New(input string) *Lexer {
 	l := &Lexer{
-		input:    input,
-		inputLen: len(input),
-		line:     1,
+		input: input,
+		line:  1,
 	}
 	// this ensures that our position, readPosition, and column number are
 	// all initialized before the caller uses the lexer
@@ -32,14 +48,165 @@ func New(input string) *Lexer {
 	return l
 }
 
+// NewReader is used to create a new lexer instance which reads the input text
+// from r as it is needed, rather than requiring the whole input up front. The
+// input is decoded as UTF-8 incrementally, and only the current token is kept
+// in memory, so large files and network connections can be lexed directly.
+// The tokens produced are identical to those produced by New for the same
+// input text.
+func NewReader(r io.Reader) *Lexer {
+	l := &Lexer{
+		window: make([]byte, 0, chunkSize),
+		reader: r,
+		line:   1,
+	}
+	l.readChar()
+	return l
+}
+
+// Err returns the first error, other than io.EOF, which was encountered while
+// reading the input. Once the reader fails, NextToken behaves as though the
+// input ended, so callers lexing a stream should check Err after receiving the
+// EOF token.
+func (l *Lexer) Err() error {
+	return l.err
+}
+
+// Errors is a getter method allowing clients to read why each ILLEGAL token
+// produced by the lexer was illegal, in the order the tokens were produced
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+// illegal records why the token is an ILLEGAL token, and returns it
+func (l *Lexer) illegal(tok token.Token, format string, args ...interface{}) token.Token {
+	msg := fmt.Sprintf("line %d column %d: %s", tok.Line, tok.Column, fmt.Sprintf(format, args...))
+	l.errors = append(l.errors, msg)
+
+	tok.Type = token.ILLEGAL
+	return tok
+}
+
+// fill ensures that at least n bytes following the current reading position
+// are held in memory, reading further chunks from the reader if required.
+// Everything before the start of the current token is discarded from the
+// window as it is refilled, by moving the current token to the front of the
+// buffer. The buffer is only grown when the current token fills it, and then
+// doubles in size, so reading a long token such as a large string literal
+// takes time in proportion to it's length. If the reader is exhausted before
+// n bytes are available, fewer bytes are held.
+func (l *Lexer) fill(n int) {
+	for l.reader != nil && len(l.window)-l.readPosition < n {
+		// slide the window forward, keeping the current token
+		if shift := l.mark; shift > 0 {
+			l.window = l.window[:copy(l.window, l.window[shift:])]
+			l.mark -= shift
+			l.position -= shift
+			l.readPosition -= shift
+		}
+
+		if cap(l.window)-len(l.window) < chunkSize {
+			grown := make([]byte, len(l.window), 2*cap(l.window)+chunkSize)
+			copy(grown, l.window)
+			l.window = grown
+		}
+
+		read, err := l.reader.Read(l.window[len(l.window):cap(l.window)])
+		l.window = l.window[:len(l.window)+read]
+
+		if err != nil {
+			if err != io.EOF {
+				l.err = err
+			}
+			l.reader = nil
+		}
+	}
+}
+
+// length returns the number of bytes of text held in memory
+func (l *Lexer) length() int {
+	if l.window != nil {
+		return len(l.window)
+	}
+	return len(l.input)
+}
+
+// decode returns the character which begins at byte offset i of the text held
+// in memory, and it's width in bytes
+func (l *Lexer) decode(i int) (rune, int) {
+	if l.window != nil {
+		return utf8.DecodeRune(l.window[i:])
+	}
+	return utf8.DecodeRuneInString(l.input[i:])
+}
+
+// literal returns the text held in memory between the byte offsets start and
+// end. This is a slice of the input when lexing a string, and a copy when
+// lexing a stream, as the window is overwritten as it is refilled
+func (l *Lexer) literal(start, end int) string {
+	if l.window != nil {
+		return string(l.window[start:end])
+	}
+	return l.input[start:end]
+}
+
 func newToken(tType token.Type, ch rune, column, line int) token.Token {
 	return token.Token{Type: tType, Literal: string(ch), Column: column, Line: line}
 }
 
-func isLetter(ch rune) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isIdentifierStart reports whether ch may begin an identifier. Identifiers
+// follow Unicode Standard Annex #31, so this is any character with the
+// XID_Start property, plus the underscore.
+func isIdentifierStart(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+	}
+
+	return unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start) &&
+		!unicode.In(ch, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDStart)
 }
 
+// isIdentifierContinue reports whether ch may appear after the first character
+// of an identifier. This is any character with the XID_Continue property,
+// which includes digits, so identifiers such as `x1` and `add2` are allowed.
+func isIdentifierContinue(ch rune) bool {
+	if ch < utf8.RuneSelf {
+		return isIdentifierStart(ch) || isDigit(ch)
+	}
+
+	return unicode.In(ch, unicode.L, unicode.Nl, unicode.Other_ID_Start, unicode.Mn, unicode.Mc, unicode.Nd, unicode.Pc, unicode.Other_ID_Continue) &&
+		!unicode.In(ch, unicode.Pattern_Syntax, unicode.Pattern_White_Space, notXIDContinue)
+}
+
+// notXIDStart and notXIDContinue are the few characters which have the
+// ID_Start and ID_Continue properties, but are dropped from XID_Start and
+// XID_Continue so that identifiers remain identifiers under NFKC
+// normalization. The unicode package only provides the ID variants of the
+// properties, so these are removed by hand.
+var (
+	notXIDStart = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+			{Lo: 0x0e33, Hi: 0x0e33, Stride: 1},
+			{Lo: 0x0eb3, Hi: 0x0eb3, Stride: 1},
+			{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+			{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+			{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+			{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+			{Lo: 0xff9e, Hi: 0xff9f, Stride: 1},
+		},
+	}
+	notXIDContinue = &unicode.RangeTable{
+		R16: []unicode.Range16{
+			{Lo: 0x037a, Hi: 0x037a, Stride: 1},
+			{Lo: 0x309b, Hi: 0x309c, Stride: 1},
+			{Lo: 0xfc5e, Hi: 0xfc63, Stride: 1},
+			{Lo: 0xfdfa, Hi: 0xfdfb, Stride: 1},
+			{Lo: 0xfe70, Hi: 0xfe7e, Stride: 2},
+		},
+	}
+)
+
 func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
@@ -53,49 +220,113 @@ func (l *Lexer) readChar() {
 		l.column = 0
 	}
 
+	// make sure that a whole character is available to be decoded, even when
+	// it's bytes are split between two chunks of a stream
+	if l.reader != nil {
+		l.fill(utf8.UTFMax)
+	}
+
 	width := 1
-	if l.readPosition >= l.inputLen {
+	if l.readPosition >= l.length() {
 		l.ch = 0
 	} else {
-		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
+		l.ch, width = l.decode(l.readPosition)
 	}
 	l.position = l.readPosition
 	l.readPosition += width
 	l.column++
 }
 
+// readIdentifier reads an identifier, and returns it in Unicode Normalization
+// Form C. This means that an identifier which is spelled with a combining
+// accent, such as `cafe\u0301`, is the same identifier as it's precomposed
+// spelling `caf\u00e9`
+func (l *Lexer) readIdentifier() string {
+	for isIdentifierContinue(l.ch) {
+		l.readChar()
+	}
+	return norm.NFC.String(l.literal(l.mark, l.position))
+}
+
+// readString reads the contents of a double quoted string, leaving the
+// closing quote as the current character. If the input ends before the
+// closing quote, the string is not terminated and false is returned.
+func (l *Lexer) readString() (string, bool) {
+	for {
+		l.readChar()
+		if l.ch == '"' || l.ch == 0 {
+			break
+		}
+	}
+
+	// skip the opening quote
+	return l.literal(l.mark+1, l.position), l.ch == '"'
+}
+
 // peekChar is similar to readChar, but instead we peek ahead at the next
 // character in the input stream rather than actually advancing forward.
 // This allows for us to look for two character tokens more easily.
 func (l *Lexer) peekChar() rune {
-	if l.readPosition >= l.inputLen {
+	if l.reader != nil {
+		l.fill(utf8.UTFMax)
+	}
+
+	if l.readPosition >= l.length() {
 		return 0
 	}
 
-	c, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	c, _ := l.decode(l.readPosition)
 
 	return c
 }
 
-func (l *Lexer) readIdentifier() string {
-	start := l.position
-	for isLetter(l.ch) {
-		l.readChar()
+// readOperator consumes the longest operator which begins at the current
+// character, as defined by the operator table. This means that `==` is read as
+// a single EQ token, rather than two ASSIGN tokens. If no operator begins at
+// the current character, nothing is consumed and false is returned.
+func (l *Lexer) readOperator() (token.Token, bool) {
+	l.fill(maxOperatorLength)
+
+	// no operator is longer than maxOperatorLength, so the copy of a stream's
+	// window made for the lookup is small enough to be kept on the stack
+	end := l.position + maxOperatorLength
+	if end > l.length() {
+		end = l.length()
+	}
+
+	var (
+		op     token.Operator
+		length int
+	)
+	if l.window != nil {
+		op, length = operators.longestMatch(string(l.window[l.position:end]))
+	} else {
+		op, length = operators.longestMatch(l.input[l.position:end])
+	}
+	if length == 0 {
+		return token.Token{}, false
 	}
-	return l.input[start:l.position]
-}
 
-func (l *Lexer) readNumber() string {
-	start := l.position
-	for isDigit(l.ch) {
+	tok := token.Token{
+		Type:    op.Type,
+		Literal: op.Literal,
+		Column:  l.column,
+		Line:    l.line,
+	}
+
+	for l.position < l.mark+length {
 		l.readChar()
 	}
-	return l.input[start:l.position]
+
+	return tok, true
 }
 
 // skipWhitespace is used to skip over general whitespace characters
 func (l *Lexer) skipWhitespace() {
 	for l.ch == ' ' || l.ch == '\t' || l.ch == '\n' || l.ch == '\r' {
+		// whitespace is never part of a token, so there is no need to
+		// keep it in memory
+		l.mark = l.position
 		l.readChar()
 	}
 }
@@ -106,56 +337,18 @@ func (l *Lexer) NextToken() token.Token {
 	var tok token.Token
 
 	l.skipWhitespace()
+	l.mark = l.position
 
 	switch l.ch {
-	case '=':
-		if l.peekChar() == '=' {
-			// get the first equal
-			ch := l.ch
-			// get the second equal and advance our position
-			l.readChar()
-			// construct the == literal
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.EQ, Literal: literal, Column: l.column - 1, Line: l.line}
-		} else {
-			tok = newToken(token.ASSIGN, l.ch, l.column, l.line)
-		}
-	case '+':
-		tok = newToken(token.PLUS, l.ch, l.column, l.line)
-	case '-':
-		tok = newToken(token.MINUS, l.ch, l.column, l.line)
-	case '!':
-		if l.peekChar() == '=' {
-			// get the first bang
-			ch := l.ch
-			// get the second equal and advance our position
-			l.readChar()
-			// construct the != literal
-			literal := string(ch) + string(l.ch)
-			tok = token.Token{Type: token.NOT_EQ, Literal: literal, Column: l.column - 1, Line: l.line}
-		} else {
-			tok = newToken(token.BANG, l.ch, l.column, l.line)
+	case '"':
+		tok.Type = token.STRING
+		tok.Column = l.column
+		tok.Line = l.line
+		var terminated bool
+		tok.Literal, terminated = l.readString()
+		if !terminated {
+			return l.illegal(tok, "string literal not terminated")
 		}
-	case '*':
-		tok = newToken(token.ASTERISK, l.ch, l.column, l.line)
-	case '/':
-		tok = newToken(token.SLASH, l.ch, l.column, l.line)
-	case '<':
-		tok = newToken(token.LT, l.ch, l.column, l.line)
-	case '>':
-		tok = newToken(token.GT, l.ch, l.column, l.line)
-	case ',':
-		tok = newToken(token.COMMA, l.ch, l.column, l.line)
-	case ';':
-		tok = newToken(token.SEMICOLON, l.ch, l.column, l.line)
-	case '(':
-		tok = newToken(token.LPAREN, l.ch, l.column, l.line)
-	case ')':
-		tok = newToken(token.RPAREN, l.ch, l.column, l.line)
-	case '{':
-		tok = newToken(token.LBRACE, l.ch, l.column, l.line)
-	case '}':
-		tok = newToken(token.RBRACE, l.ch, l.column, l.line)
 	case 0:
 		// EOF case
 		tok.Literal = ""
@@ -163,20 +356,28 @@ func (l *Lexer) NextToken() token.Token {
 		tok.Column = l.column - 1
 		tok.Line = l.line
 	default:
-		if unicode.IsLetter(l.ch) {
+		if op, ok := l.readOperator(); ok {
+			return op
+		}
+
+		// the position is recorded before the token is read, as columns
+		// count characters rather than bytes
+		tok.Column = l.column
+		tok.Line = l.line
+
+		if isIdentifierStart(l.ch) {
 			tok.Literal = l.readIdentifier()
 			tok.Type = token.LookupIdent(tok.Literal)
-			tok.Column = l.column - len(tok.Literal)
-			tok.Line = l.line
 			return tok
-		} else if unicode.IsNumber(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
-			tok.Column = l.column - len(tok.Literal)
-			tok.Line = l.line
+		} else if isDigit(l.ch) {
+			var problem string
+			tok.Type, tok.Literal, problem = l.readNumber()
+			if problem != "" {
+				return l.illegal(tok, "%s in %q", problem, tok.Literal)
+			}
 			return tok
 		}
-		tok = newToken(token.ILLEGAL, l.ch, l.column-1, l.line)
+		tok = l.illegal(newToken(token.ILLEGAL, l.ch, l.column, l.line), "illegal character %q", l.ch)
 	}
 
 	l.readChar()
@@ -1,7 +1,12 @@
 package lexer
 
 import (
+	"fmt"
+	"io"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"unicode/utf8"
 
 	"github.com/kkirsche/monkey/token"
 	"github.com/stretchr/testify/require"
@@ -117,6 +122,441 @@ if (5 < 10) {
 		expected{token.EOF, "", 0, 20},
 	}
 
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenTypeAnnotations(t *testing.T) {
+	input := `let x: int = 5;
+fn(a: int) -> int { a - 1 };
+`
+
+	tests := []expected{
+		expected{token.LET, "let", 1, 1},
+		expected{token.IDENT, "x", 5, 1},
+		expected{token.COLON, ":", 6, 1},
+		expected{token.IDENT, "int", 8, 1},
+		expected{token.ASSIGN, "=", 12, 1},
+		expected{token.INT, "5", 14, 1},
+		expected{token.SEMICOLON, ";", 15, 1},
+		expected{token.FUNCTION, "fn", 1, 2},
+		expected{token.LPAREN, "(", 3, 2},
+		expected{token.IDENT, "a", 4, 2},
+		expected{token.COLON, ":", 5, 2},
+		expected{token.IDENT, "int", 7, 2},
+		expected{token.RPAREN, ")", 10, 2},
+		expected{token.ARROW, "->", 12, 2},
+		expected{token.IDENT, "int", 15, 2},
+		expected{token.LBRACE, "{", 19, 2},
+		expected{token.IDENT, "a", 21, 2},
+		expected{token.MINUS, "-", 23, 2},
+		expected{token.INT, "1", 25, 2},
+		expected{token.RBRACE, "}", 27, 2},
+		expected{token.SEMICOLON, ";", 28, 2},
+		expected{token.EOF, "", 0, 3},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenComparisonAndLogicalOperators(t *testing.T) {
+	input := `a <= b >= c;
+a && b || c;
+a & b | c;
+`
+
+	tests := []expected{
+		expected{token.IDENT, "a", 1, 1},
+		expected{token.LT_EQ, "<=", 3, 1},
+		expected{token.IDENT, "b", 6, 1},
+		expected{token.GT_EQ, ">=", 8, 1},
+		expected{token.IDENT, "c", 11, 1},
+		expected{token.SEMICOLON, ";", 12, 1},
+		expected{token.IDENT, "a", 1, 2},
+		expected{token.AND, "&&", 3, 2},
+		expected{token.IDENT, "b", 6, 2},
+		expected{token.OR, "||", 8, 2},
+		expected{token.IDENT, "c", 11, 2},
+		expected{token.SEMICOLON, ";", 12, 2},
+		expected{token.IDENT, "a", 1, 3},
+		expected{token.ILLEGAL, "&", 3, 3},
+		expected{token.IDENT, "b", 5, 3},
+		expected{token.ILLEGAL, "|", 7, 3},
+		expected{token.IDENT, "c", 9, 3},
+		expected{token.SEMICOLON, ";", 10, 3},
+		expected{token.EOF, "", 0, 4},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenIdentifiers(t *testing.T) {
+	input := `let héllo = x1 + add2;
+let 名前 = _privé;
+let café = Ωmega_2;
+let ٣ = 3;
+x٣ ℘ ·y
+`
+
+	tests := []expected{
+		expected{token.LET, "let", 1, 1},
+		expected{token.IDENT, "héllo", 5, 1},
+		expected{token.ASSIGN, "=", 11, 1},
+		expected{token.IDENT, "x1", 13, 1},
+		expected{token.PLUS, "+", 16, 1},
+		expected{token.IDENT, "add2", 18, 1},
+		expected{token.SEMICOLON, ";", 22, 1},
+		expected{token.LET, "let", 1, 2},
+		expected{token.IDENT, "名前", 5, 2},
+		expected{token.ASSIGN, "=", 8, 2},
+		expected{token.IDENT, "_privé", 10, 2},
+		expected{token.SEMICOLON, ";", 16, 2},
+		expected{token.LET, "let", 1, 3},
+		// the combining acute accent continues the identifier, and is
+		// counted as a column of it's own, but the literal is normalized to
+		// the single precomposed character
+		expected{token.IDENT, "caf\u00e9", 5, 3},
+		expected{token.ASSIGN, "=", 11, 3},
+		expected{token.IDENT, "Ωmega_2", 13, 3},
+		expected{token.SEMICOLON, ";", 20, 3},
+		expected{token.LET, "let", 1, 4},
+		// a digit which is not ASCII may not begin a number or identifier
+		expected{token.ILLEGAL, "٣", 5, 4},
+		expected{token.ASSIGN, "=", 7, 4},
+		expected{token.INT, "3", 9, 4},
+		expected{token.SEMICOLON, ";", 10, 4},
+		// but it may continue an identifier
+		expected{token.IDENT, "x\u0663", 1, 5},
+		// Other_ID_Start may begin an identifier, Other_ID_Continue may not
+		expected{token.IDENT, "℘", 4, 5},
+		expected{token.ILLEGAL, "·", 6, 5},
+		expected{token.IDENT, "y", 7, 5},
+		expected{token.EOF, "", 0, 6},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestIdentifiersAreNormalized(t *testing.T) {
+	composed := New("caf\u00e9").NextToken()
+	decomposed := New("cafe\u0301").NextToken()
+
+	require.Equal(t, token.IDENT, composed.Type)
+	require.Equal(t, token.IDENT, decomposed.Type)
+	require.Equal(t, "caf\u00e9", composed.Literal)
+	require.Equal(t, composed.Literal, decomposed.Literal, "the NFC and NFD spellings of café are different identifiers")
+}
+
+func TestIdentifierCharacters(t *testing.T) {
+	tests := []struct {
+		ch        rune
+		start     bool
+		continues bool
+	}{
+		{'a', true, true},
+		{'Z', true, true},
+		{'_', true, true},
+		{'7', false, true},
+		{'é', true, true},
+		{'名', true, true},
+		{'Ⅻ', true, true},        // Nl, letter number
+		{'\u0301', false, true},  // Mn, combining acute accent
+		{'\u0663', false, true},  // Nd, arabic-indic digit three
+		{'‿', false, true},       // Pc, undertie
+		{'·', false, true},       // Other_ID_Continue, middle dot
+		{'\u037a', false, false}, // ID_Start, but not XID_Start or XID_Continue
+		{'\u0e33', false, true},  // ID_Start, but only XID_Continue
+		{'$', false, false},
+		{'-', false, false},
+		{' ', false, false},
+		{'⸀', false, false}, // Pattern_Syntax
+		{'€', false, false},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.start, isIdentifierStart(tt.ch), "Invalid isIdentifierStart for %q", tt.ch)
+		require.Equal(t, tt.continues, isIdentifierContinue(tt.ch), "Invalid isIdentifierContinue for %q", tt.ch)
+	}
+}
+
+func TestNextTokenNumbers(t *testing.T) {
+	input := `3.14 1e9 2.5E-3 1e+2 0xff 0XFF 0o17 0b1010 1_000_000 0x_dead_beef
+1.x 1..2 0xffz 017
+`
+
+	tests := []expected{
+		expected{token.FLOAT, "3.14", 1, 1},
+		expected{token.FLOAT, "1e9", 6, 1},
+		expected{token.FLOAT, "2.5E-3", 10, 1},
+		expected{token.FLOAT, "1e+2", 17, 1},
+		expected{token.INT, "0xff", 22, 1},
+		expected{token.INT, "0XFF", 27, 1},
+		expected{token.INT, "0o17", 32, 1},
+		expected{token.INT, "0b1010", 37, 1},
+		expected{token.INT, "1_000_000", 44, 1},
+		expected{token.INT, "0x_dead_beef", 54, 1},
+		// a fraction requires a digit after the point
+		expected{token.INT, "1", 1, 2},
+		expected{token.DOT, ".", 2, 2},
+		expected{token.IDENT, "x", 3, 2},
+		expected{token.INT, "1", 5, 2},
+		expected{token.DOT, ".", 6, 2},
+		expected{token.DOT, ".", 7, 2},
+		expected{token.INT, "2", 8, 2},
+		expected{token.INT, "0xff", 10, 2},
+		expected{token.IDENT, "z", 14, 2},
+		expected{token.INT, "017", 16, 2},
+		expected{token.EOF, "", 0, 3},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenMalformedNumbers(t *testing.T) {
+	tests := []struct {
+		input    string
+		literal  string
+		expected string
+	}{
+		{"0x", "0x", "line 1 column 1: hexadecimal literal has no digits in \"0x\""},
+		{"0o;", "0o", "line 1 column 1: octal literal has no digits in \"0o\""},
+		{"0b_", "0b_", "line 1 column 1: binary literal has no digits in \"0b_\""},
+		{"0b102", "0b102", "line 1 column 1: invalid digit '2' in binary literal in \"0b102\""},
+		{"0o78", "0o78", "line 1 column 1: invalid digit '8' in octal literal in \"0o78\""},
+		{"1__0", "1__0", "line 1 column 1: '_' must separate successive digits in \"1__0\""},
+		{"1_", "1_", "line 1 column 1: '_' must separate successive digits in \"1_\""},
+		{"1_.5", "1_.5", "line 1 column 1: '_' must separate successive digits in \"1_.5\""},
+		{"0x__1", "0x__1", "line 1 column 1: '_' must separate successive digits in \"0x__1\""},
+		{"1e", "1e", "line 1 column 1: exponent has no digits in \"1e\""},
+		{"  2.5e+;", "2.5e+", "line 1 column 3: exponent has no digits in \"2.5e+\""},
+		{"@", "@", "line 1 column 1: illegal character '@'"},
+	}
+
+	for _, tt := range tests {
+		lex := New(tt.input)
+		tok := lex.NextToken()
+
+		require.Equal(t, token.ILLEGAL, tok.Type, "Invalid token type '%s' for input %q", tok.Type, tt.input)
+		require.Equal(t, tt.literal, tok.Literal, "Invalid token literal '%s' for input %q", tok.Literal, tt.input)
+		require.Equal(t, []string{tt.expected}, lex.Errors(), "Invalid errors for input %q", tt.input)
+	}
+}
+
+func TestNextTokenStrings(t *testing.T) {
+	input := `"foobar" "foo bar" "" {"name": "x", 1: true}
+"multi
+line" "héllo";"unterminated`
+
+	tests := []expected{
+		expected{token.STRING, "foobar", 1, 1},
+		expected{token.STRING, "foo bar", 10, 1},
+		expected{token.STRING, "", 20, 1},
+		expected{token.LBRACE, "{", 23, 1},
+		expected{token.STRING, "name", 24, 1},
+		expected{token.COLON, ":", 30, 1},
+		expected{token.STRING, "x", 32, 1},
+		expected{token.COMMA, ",", 35, 1},
+		expected{token.INT, "1", 37, 1},
+		expected{token.COLON, ":", 38, 1},
+		expected{token.TRUE, "true", 40, 1},
+		expected{token.RBRACE, "}", 44, 1},
+		expected{token.STRING, "multi\nline", 1, 2},
+		expected{token.STRING, "héllo", 7, 3},
+		expected{token.SEMICOLON, ";", 14, 3},
+		expected{token.ILLEGAL, "unterminated", 15, 3},
+		expected{token.EOF, "", 27, 3},
+	}
+
+	testTokens(t, input, tests)
+
+	lex := New(input)
+	lexAll(lex)
+	require.Equal(t, []string{"line 3 column 15: string literal not terminated"}, lex.Errors())
+}
+
+func TestNextTokenTryCatch(t *testing.T) {
+	input := `try { throw "oops"; } catch (e) { e } finally { 1 }`
+
+	tests := []expected{
+		expected{token.TRY, "try", 1, 1},
+		expected{token.LBRACE, "{", 5, 1},
+		expected{token.THROW, "throw", 7, 1},
+		expected{token.STRING, "oops", 13, 1},
+		expected{token.SEMICOLON, ";", 19, 1},
+		expected{token.RBRACE, "}", 21, 1},
+		expected{token.CATCH, "catch", 23, 1},
+		expected{token.LPAREN, "(", 29, 1},
+		expected{token.IDENT, "e", 30, 1},
+		expected{token.RPAREN, ")", 31, 1},
+		expected{token.LBRACE, "{", 33, 1},
+		expected{token.IDENT, "e", 35, 1},
+		expected{token.RBRACE, "}", 37, 1},
+		expected{token.FINALLY, "finally", 39, 1},
+		expected{token.LBRACE, "{", 47, 1},
+		expected{token.INT, "1", 49, 1},
+		expected{token.RBRACE, "}", 51, 1},
+		expected{token.EOF, "", 51, 1},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenModules(t *testing.T) {
+	input := `import "path/to/mod" as m;
+export let x = m;`
+
+	tests := []expected{
+		expected{token.IMPORT, "import", 1, 1},
+		expected{token.STRING, "path/to/mod", 8, 1},
+		expected{token.AS, "as", 22, 1},
+		expected{token.IDENT, "m", 25, 1},
+		expected{token.SEMICOLON, ";", 26, 1},
+		expected{token.EXPORT, "export", 1, 2},
+		expected{token.LET, "let", 8, 2},
+		expected{token.IDENT, "x", 12, 2},
+		expected{token.ASSIGN, "=", 14, 2},
+		expected{token.IDENT, "m", 16, 2},
+		expected{token.SEMICOLON, ";", 17, 2},
+		expected{token.EOF, "", 17, 2},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenLoops(t *testing.T) {
+	input := `while (x) { break; }
+for (y in z) { continue; }`
+
+	tests := []expected{
+		expected{token.WHILE, "while", 1, 1},
+		expected{token.LPAREN, "(", 7, 1},
+		expected{token.IDENT, "x", 8, 1},
+		expected{token.RPAREN, ")", 9, 1},
+		expected{token.LBRACE, "{", 11, 1},
+		expected{token.BREAK, "break", 13, 1},
+		expected{token.SEMICOLON, ";", 18, 1},
+		expected{token.RBRACE, "}", 20, 1},
+		expected{token.FOR, "for", 1, 2},
+		expected{token.LPAREN, "(", 5, 2},
+		expected{token.IDENT, "y", 6, 2},
+		expected{token.IN, "in", 8, 2},
+		expected{token.IDENT, "z", 11, 2},
+		expected{token.RPAREN, ")", 12, 2},
+		expected{token.LBRACE, "{", 14, 2},
+		expected{token.CONTINUE, "continue", 16, 2},
+		expected{token.SEMICOLON, ";", 24, 2},
+		expected{token.RBRACE, "}", 26, 2},
+		expected{token.EOF, "", 26, 2},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenMatch(t *testing.T) {
+	input := `match (x) { _ => _x, }`
+
+	tests := []expected{
+		expected{token.MATCH, "match", 1, 1},
+		expected{token.LPAREN, "(", 7, 1},
+		expected{token.IDENT, "x", 8, 1},
+		expected{token.RPAREN, ")", 9, 1},
+		expected{token.LBRACE, "{", 11, 1},
+		// a lone underscore is an identifier, which the parser treats as a
+		// wildcard where a pattern is expected
+		expected{token.IDENT, "_", 13, 1},
+		expected{token.FATARROW, "=>", 15, 1},
+		expected{token.IDENT, "_x", 18, 1},
+		expected{token.COMMA, ",", 20, 1},
+		expected{token.RBRACE, "}", 22, 1},
+		expected{token.EOF, "", 22, 1},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenNullish(t *testing.T) {
+	input := `a?.b ?? c?.[0] ?? null`
+
+	tests := []expected{
+		expected{token.IDENT, "a", 1, 1},
+		expected{token.QUESTION_DOT, "?.", 2, 1},
+		expected{token.IDENT, "b", 4, 1},
+		expected{token.NULLISH, "??", 6, 1},
+		expected{token.IDENT, "c", 9, 1},
+		expected{token.QUESTION_DOT, "?.", 10, 1},
+		expected{token.LBRACKET, "[", 12, 1},
+		expected{token.INT, "0", 13, 1},
+		expected{token.RBRACKET, "]", 14, 1},
+		expected{token.NULLISH, "??", 16, 1},
+		expected{token.NULL, "null", 19, 1},
+		expected{token.EOF, "", 22, 1},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func TestNextTokenOperatorTable(t *testing.T) {
+	for _, op := range token.Operators {
+		width := utf8.RuneCountInString(op.Literal)
+
+		// surrounded by whitespace
+		testTokens(t, fmt.Sprintf("x %s y", op.Literal), []expected{
+			expected{token.IDENT, "x", 1, 1},
+			expected{op.Type, op.Literal, 3, 1},
+			expected{token.IDENT, "y", 4 + width, 1},
+			expected{token.EOF, "", 4 + width, 1},
+		})
+
+		// directly adjacent to it's operands
+		testTokens(t, fmt.Sprintf("x%sy", op.Literal), []expected{
+			expected{token.IDENT, "x", 1, 1},
+			expected{op.Type, op.Literal, 2, 1},
+			expected{token.IDENT, "y", 2 + width, 1},
+			expected{token.EOF, "", 2 + width, 1},
+		})
+
+		// on it's own on the second line
+		testTokens(t, fmt.Sprintf("\n  %s\n", op.Literal), []expected{
+			expected{op.Type, op.Literal, 3, 2},
+			expected{token.EOF, "", 0, 3},
+		})
+	}
+}
+
+func TestOperatorTableIsUnique(t *testing.T) {
+	literals := map[string]bool{}
+	types := map[token.Type]bool{}
+
+	for _, op := range token.Operators {
+		require.NotEmpty(t, op.Literal, "operator %s has an empty literal", op.Type)
+		require.False(t, literals[op.Literal], "operator literal '%s' is defined more than once", op.Literal)
+		require.False(t, types[op.Type], "operator type '%s' is defined more than once", op.Type)
+
+		literals[op.Literal] = true
+		types[op.Type] = true
+	}
+}
+
+func TestNextTokenLongestMatch(t *testing.T) {
+	input := `!== <== ->= &&& |||`
+
+	tests := []expected{
+		expected{token.NOT_EQ, "!=", 1, 1},
+		expected{token.ASSIGN, "=", 3, 1},
+		expected{token.LT_EQ, "<=", 5, 1},
+		expected{token.ASSIGN, "=", 7, 1},
+		expected{token.ARROW, "->", 9, 1},
+		expected{token.ASSIGN, "=", 11, 1},
+		expected{token.AND, "&&", 13, 1},
+		expected{token.ILLEGAL, "&", 15, 1},
+		expected{token.OR, "||", 17, 1},
+		expected{token.ILLEGAL, "|", 19, 1},
+		expected{token.EOF, "", 19, 1},
+	}
+
+	testTokens(t, input, tests)
+}
+
+func testTokens(t *testing.T, input string, tests []expected) {
 	lex := New(input)
 
 	for _, tt := range tests {
@@ -127,4 +567,139 @@ if (5 < 10) {
 		require.Equal(t, tt.tColumn, tok.Column, "Invalid column number %d for token literal '%s'", tok.Column, tok.Literal)
 		require.Equal(t, tt.tLine, tok.Line, "Invalid line number %d for token literal '%s'", tok.Line, tok.Literal)
 	}
+
+	testReaderMatches(t, input)
+}
+
+// readers are the ways a stream may deliver the input text to NewReader. Each
+// splits the input into chunks differently, so characters and tokens end up
+// divided between reads
+var readers = map[string]func(string) io.Reader{
+	"whole": func(input string) io.Reader {
+		return strings.NewReader(input)
+	},
+	"one byte": func(input string) io.Reader {
+		return iotest.OneByteReader(strings.NewReader(input))
+	},
+	"half": func(input string) io.Reader {
+		return iotest.HalfReader(strings.NewReader(input))
+	},
+	"data with EOF": func(input string) io.Reader {
+		return iotest.DataErrReader(strings.NewReader(input))
+	},
+}
+
+// testReaderMatches is a differential test which checks that lexing the input
+// from a stream produces exactly the same tokens as lexing it from a string
+func testReaderMatches(t *testing.T, input string) {
+	want := lexAll(New(input))
+
+	for name, reader := range readers {
+		lex := NewReader(reader(input))
+		got := lexAll(lex)
+
+		require.NoError(t, lex.Err(), "Unexpected error from the %s reader", name)
+		require.Equal(t, want, got, "Tokens from the %s reader differ from the string lexer for input %q", name, input)
+	}
+}
+
+// lexAll reads every token, up to and including EOF, from the lexer
+func lexAll(lex *Lexer) []token.Token {
+	var tokens []token.Token
+	for {
+		tok := lex.NextToken()
+		tokens = append(tokens, tok)
+		if tok.Type == token.EOF {
+			return tokens
+		}
+	}
+}
+
+func TestNewReaderMatchesNew(t *testing.T) {
+	inputs := []string{
+		"",
+		"   \n\t\r\n  ",
+		"let € = ✓;",
+		"let x = \xff\xfe 5;",
+		strings.Repeat("a", 3*chunkSize+7) + " == " + strings.Repeat("9", chunkSize),
+		strings.Repeat(" ", 2*chunkSize) + "x\n" + strings.Repeat("\n", chunkSize) + "y",
+		strings.Repeat("€+", chunkSize),
+		benchmarkInput[:10*chunkSize],
+	}
+
+	for _, input := range inputs {
+		testReaderMatches(t, input)
+	}
+}
+
+func TestNewReaderLongToken(t *testing.T) {
+	// a token many times larger than a chunk, read a byte at a time, makes
+	// the window refill once for every byte of the token
+	contents := strings.Repeat("monkey ", 1<<17)
+	lex := NewReader(iotest.OneByteReader(strings.NewReader(`let s = "` + contents + `";`)))
+
+	for _, tType := range []token.Type{token.LET, token.IDENT, token.ASSIGN} {
+		require.Equal(t, tType, lex.NextToken().Type)
+	}
+
+	tok := lex.NextToken()
+	require.Equal(t, token.STRING, tok.Type)
+	require.True(t, tok.Literal == contents, "the string literal was not read in full")
+	require.Equal(t, token.SEMICOLON, lex.NextToken().Type)
+	require.Equal(t, token.EOF, lex.NextToken().Type)
+}
+
+func TestNewReaderError(t *testing.T) {
+	lex := NewReader(iotest.TimeoutReader(strings.NewReader("let x = 5;")))
+
+	tests := []expected{
+		expected{token.LET, "let", 1, 1},
+		expected{token.IDENT, "x", 5, 1},
+		expected{token.ASSIGN, "=", 7, 1},
+		expected{token.INT, "5", 9, 1},
+		expected{token.SEMICOLON, ";", 10, 1},
+		expected{token.EOF, "", 10, 1},
+	}
+
+	for _, tt := range tests {
+		tok := lex.NextToken()
+
+		require.Equal(t, tt.tType, tok.Type, "Invalid token type '%s', expected '%s'", tok.Type, tt.tType)
+		require.Equal(t, tt.tLiteral, tok.Literal, "Invalid token literal '%s', expected '%s'", tok.Literal, tt.tLiteral)
+		require.Equal(t, tt.tColumn, tok.Column, "Invalid column number %d for token literal '%s'", tok.Column, tok.Literal)
+		require.Equal(t, tt.tLine, tok.Line, "Invalid line number %d for token literal '%s'", tok.Line, tok.Literal)
+	}
+
+	require.Equal(t, iotest.ErrTimeout, lex.Err())
+}
+
+// benchmarkInput is a multi-megabyte program used to measure the throughput
+// of the lexer on large generated scripts
+var benchmarkInput = strings.Repeat(`let five = 5;
+let ten = 10;
+let add = fn(x, y) { x + y; };
+let result = add(five, ten) * (five - ten) / 2;
+if (result >= 10 && result <= 100 || result != 42) { return true; } else { return false; }
+`, 20000)
+
+func BenchmarkNextToken(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkInput)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		lex := New(benchmarkInput)
+		for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
+		}
+	}
+}
+
+func BenchmarkNextTokenReader(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkInput)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		lex := NewReader(strings.NewReader(benchmarkInput))
+		for tok := lex.NextToken(); tok.Type != token.EOF; tok = lex.NextToken() {
+		}
+	}
 }
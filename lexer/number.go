@@ -0,0 +1,142 @@
+package lexer
+
+import (
+	"fmt"
+
+	"github.com/kkirsche/monkey/token"
+)
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= lower(ch) && lower(ch) <= 'f'
+}
+
+// lower returns the lower case form of an ASCII letter
+func lower(ch rune) rune {
+	return ('a' - 'A') | ch
+}
+
+// numberBases maps the letter following the leading zero of a prefixed integer
+// literal, such as the x in 0xff, to the base of the literal and it's name
+var numberBases = map[rune]struct {
+	base int
+	name string
+}{
+	'x': {16, "hexadecimal"},
+	'o': {8, "octal"},
+	'b': {2, "binary"},
+}
+
+// readNumber reads an integer or floating point literal. The supported forms
+// are decimal integers (42), hexadecimal (0xff), octal (0o17) and binary
+// (0b1010) integers, and decimal floats with a fraction, an exponent or both
+// (3.14, 1e9, 2.5e-3). Underscores may be used to separate digits, as in
+// 1_000_000.
+//
+// When the literal is malformed, the whole literal is still consumed and a
+// description of the problem is returned along with it, so that the caller is
+// able to report exactly what is wrong.
+func (l *Lexer) readNumber() (token.Type, string, string) {
+	tType := token.INT
+	base, name := 10, "decimal"
+	digits := 0
+	var invalid rune
+
+	if l.ch == '0' {
+		if prefix, ok := numberBases[lower(l.peekChar())]; ok {
+			base, name = prefix.base, prefix.name
+			l.readChar()
+			l.readChar()
+		}
+	}
+
+	// octal and binary literals consume every decimal digit, so that a digit
+	// out of range is reported rather than silently starting a new token
+	for isDigit(l.ch) || base == 16 && isHexDigit(l.ch) || l.ch == '_' {
+		if l.ch != '_' {
+			digits++
+			if base < 10 && l.ch-'0' >= rune(base) && invalid == 0 {
+				invalid = l.ch
+			}
+		}
+		l.readChar()
+	}
+
+	var problem string
+	switch {
+	case digits == 0:
+		problem = fmt.Sprintf("%s literal has no digits", name)
+	case invalid != 0:
+		problem = fmt.Sprintf("invalid digit %q in %s literal", invalid, name)
+	}
+
+	if base == 10 {
+		// a fraction requires a digit after the point, so that `1.` is left
+		// for whatever follows the integer
+		if l.ch == '.' && isDigit(l.peekChar()) {
+			tType = token.FLOAT
+			l.readChar()
+			for isDigit(l.ch) || l.ch == '_' {
+				l.readChar()
+			}
+		}
+
+		if lower(l.ch) == 'e' {
+			tType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+
+			if !isDigit(l.ch) && problem == "" {
+				problem = "exponent has no digits"
+			}
+			for isDigit(l.ch) || l.ch == '_' {
+				l.readChar()
+			}
+		}
+	}
+
+	literal := l.literal(l.mark, l.position)
+	if problem == "" && invalidSeparator(literal) {
+		problem = "'_' must separate successive digits"
+	}
+
+	return tType, literal, problem
+}
+
+// invalidSeparator reports whether an underscore in the number literal is
+// misplaced. An underscore must sit between two digits, or directly after
+// the base prefix of an integer.
+func invalidSeparator(literal string) bool {
+	hex := false
+	prev := '.' // one of '0' (a digit), '_' or '.' (anything else)
+	i := 0
+
+	if len(literal) >= 2 && literal[0] == '0' {
+		if _, ok := numberBases[lower(rune(literal[1]))]; ok {
+			hex = lower(rune(literal[1])) == 'x'
+			// the prefix counts as a digit
+			prev = '0'
+			i = 2
+		}
+	}
+
+	for _, ch := range literal[i:] {
+		switch {
+		case ch == '_':
+			if prev != '0' {
+				return true
+			}
+			prev = '_'
+		case isDigit(ch) || hex && isHexDigit(ch):
+			prev = '0'
+		default:
+			if prev == '_' {
+				return true
+			}
+			prev = '.'
+		}
+	}
+
+	return prev == '_'
+}
@@ -0,0 +1,81 @@
+package lexer
+
+import (
+	"unicode/utf8"
+
+	"github.com/kkirsche/monkey/token"
+)
+
+// operatorNode is a single node of the operator trie. Each edge is a rune of
+// an operator literal, and a node is terminal when the path leading to it
+// spells out a complete operator
+type operatorNode struct {
+	children map[rune]*operatorNode
+	terminal bool
+	op       token.Operator
+}
+
+// operators is the trie of every operator defined in token.Operators. It is
+// built once and shared by every lexer instance
+var operators = newOperatorTrie(token.Operators)
+
+// maxOperatorLength is the length, in bytes, of the longest operator literal.
+// This is the most input the lexer needs to look at to find the longest match
+var maxOperatorLength = func() int {
+	max := 0
+	for _, op := range token.Operators {
+		if len(op.Literal) > max {
+			max = len(op.Literal)
+		}
+	}
+	return max
+}()
+
+// newOperatorTrie builds the operator trie from the operator definition list
+func newOperatorTrie(ops []token.Operator) *operatorNode {
+	root := &operatorNode{children: map[rune]*operatorNode{}}
+
+	for _, op := range ops {
+		node := root
+		for _, ch := range op.Literal {
+			next, ok := node.children[ch]
+			if !ok {
+				next = &operatorNode{children: map[rune]*operatorNode{}}
+				node.children[ch] = next
+			}
+			node = next
+		}
+		node.terminal = true
+		node.op = op
+	}
+
+	return root
+}
+
+// longestMatch walks the trie along the input and returns the definition and
+// byte length of the longest operator which the input begins with. If the
+// input does not begin with an operator, the returned length is zero.
+func (n *operatorNode) longestMatch(input string) (token.Operator, int) {
+	var (
+		op     token.Operator
+		length int
+	)
+
+	node := n
+	for i := 0; i < len(input); {
+		ch, width := utf8.DecodeRuneInString(input[i:])
+		next, ok := node.children[ch]
+		if !ok {
+			break
+		}
+		node = next
+		i += width
+
+		if node.terminal {
+			op = node.op
+			length = i
+		}
+	}
+
+	return op, length
+}
@@ -8,6 +8,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(check(os.Args[2:], os.Stderr))
+	}
+
 	user, err := user.Current()
 	if err != nil {
 		panic(err)
@@ -0,0 +1,14 @@
+package module
+
+/*
+	Package module implements the module system of the Monkey programming
+	language. A module is a single Monkey source file, which may import other
+	modules with `import "path/to/mod" as m;` and expose it's own bindings to
+	them with `export let x = ...;`.
+
+	The Resolver is responsible for turning the path in an import statement
+	into a source file, loading and parsing it, and doing the same for each of
+	it's imports in turn. Relative paths, those beginning with ./ or ../, are
+	found relative to the importing file. All other paths are searched for in
+	each directory of the MONKEYPATH list, in order.
+*/
@@ -0,0 +1,223 @@
+package module
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kkirsche/monkey/ast"
+	"github.com/kkirsche/monkey/lexer"
+	"github.com/kkirsche/monkey/parser"
+)
+
+// Extension is the file extension of Monkey source files. It may be left out
+// of import paths.
+const Extension = ".mk"
+
+// Module is a single loaded and parsed Monkey source file
+type Module struct {
+	Path    string       // the absolute path of the source file
+	Program *ast.Program // the parsed source file
+	// Imports maps the alias of each import statement in the module to the
+	// module it resolved to
+	Imports map[string]*Module
+	// Exports is the name of each binding exported by the module, in the
+	// order they were declared
+	Exports []string
+}
+
+// Error is an error loading a module. When the error was caused by an import
+// statement, such as a module which could not be found or an import cycle,
+// File, Line and Column point at that import statement.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+// Error implements the error interface
+func (e *Error) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	}
+
+	return fmt.Sprintf("%s: line %d column %d: %s", e.File, e.Line, e.Column, e.Msg)
+}
+
+// ParseError is returned when a module contains syntax errors
+type ParseError struct {
+	File   string
+	Errors []string // the errors reported by the parser
+}
+
+// Error implements the error interface
+func (e *ParseError) Error() string {
+	return e.File + ": " + strings.Join(e.Errors, "\n"+e.File+": ")
+}
+
+// SearchPath returns the list of directories in the MONKEYPATH environment
+// variable. The directories are separated in the same way as the PATH
+// environment variable of the operating system.
+func SearchPath() []string {
+	return filepath.SplitList(os.Getenv("MONKEYPATH"))
+}
+
+// Resolver loads modules and their imports. Each module is loaded only once,
+// however many times it is imported, so that every importer shares the same
+// Module.
+type Resolver struct {
+	paths   []string
+	cache   map[string]*Module
+	loading []string // the modules currently being loaded, outermost first
+}
+
+// NewResolver creates a resolver which searches the given directories, in
+// order, for imports which are not relative paths. SearchPath may be used to
+// search the directories in MONKEYPATH.
+func NewResolver(paths []string) *Resolver {
+	return &Resolver{
+		paths: paths,
+		cache: map[string]*Module{},
+	}
+}
+
+// Load loads the module at the given file path, along with every module which
+// it imports, directly or indirectly
+func (r *Resolver) Load(path string) (*Module, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, &Error{File: path, Msg: err.Error()}
+	}
+
+	return r.load(abs)
+}
+
+func (r *Resolver) load(path string) (*Module, error) {
+	if m, ok := r.cache[path]; ok {
+		return m, nil
+	}
+
+	program, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Module{
+		Path:    path,
+		Program: program,
+		Imports: map[string]*Module{},
+	}
+
+	r.loading = append(r.loading, path)
+	defer func() { r.loading = r.loading[:len(r.loading)-1] }()
+
+	for _, stmt := range program.Statements {
+		switch stmt := stmt.(type) {
+		case *ast.ImportStatement:
+			if err := r.loadImport(m, stmt); err != nil {
+				return nil, err
+			}
+		case *ast.ExportStatement:
+			for _, name := range ast.Bindings(stmt.Statement.Name) {
+				m.Exports = append(m.Exports, name.Value)
+			}
+		}
+	}
+
+	r.cache[path] = m
+	return m, nil
+}
+
+// loadImport resolves and loads the module named by the import statement, and
+// adds it to the imports of the importing module
+func (r *Resolver) loadImport(m *Module, stmt *ast.ImportStatement) error {
+	importError := func(format string, args ...interface{}) error {
+		return &Error{
+			File:   m.Path,
+			Line:   stmt.Token.Line,
+			Column: stmt.Token.Column,
+			Msg:    fmt.Sprintf(format, args...),
+		}
+	}
+
+	alias := stmt.Alias.Value
+	if _, ok := m.Imports[alias]; ok {
+		return importError("%s is imported more than once", alias)
+	}
+
+	path, ok := r.resolve(m.Path, stmt.Path.Value)
+	if !ok {
+		return importError("cannot find module %q", stmt.Path.Value)
+	}
+
+	for i, loading := range r.loading {
+		if loading == path {
+			cycle := append(append([]string{}, r.loading[i:]...), path)
+			return importError("import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	imported, err := r.load(path)
+	if err != nil {
+		return err
+	}
+
+	m.Imports[alias] = imported
+	return nil
+}
+
+// resolve finds the source file for an import path found in the module at
+// from, returning it's absolute path
+func (r *Resolver) resolve(from, importPath string) (string, bool) {
+	if !strings.HasSuffix(importPath, Extension) {
+		importPath += Extension
+	}
+
+	var candidates []string
+	if strings.HasPrefix(importPath, "./") || strings.HasPrefix(importPath, "../") {
+		candidates = []string{filepath.Join(filepath.Dir(from), importPath)}
+	} else {
+		for _, dir := range r.paths {
+			candidates = append(candidates, filepath.Join(dir, importPath))
+		}
+	}
+
+	for _, candidate := range candidates {
+		info, err := os.Stat(candidate)
+		if err != nil || info.IsDir() {
+			continue
+		}
+
+		abs, err := filepath.Abs(candidate)
+		if err != nil {
+			continue
+		}
+		return abs, true
+	}
+
+	return "", false
+}
+
+// parse reads and parses the source file at path
+func parse(path string) (*ast.Program, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, &Error{File: path, Msg: err.Error()}
+	}
+	defer f.Close()
+
+	l := lexer.NewReader(f)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if err := l.Err(); err != nil {
+		return nil, &Error{File: path, Msg: err.Error()}
+	}
+	if len(p.Errors()) != 0 {
+		return nil, &ParseError{File: path, Errors: p.Errors()}
+	}
+
+	return program, nil
+}
@@ -0,0 +1,158 @@
+package module
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFiles creates a temporary directory containing the given files, keyed
+// by their path relative to the directory, and returns the directory
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "monkey-module")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	for name, contents := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0644))
+	}
+
+	// resolve any symlinks, such as /tmp on macOS, so that the paths in
+	// errors match the paths the resolver sees
+	dir, err = filepath.EvalSymlinks(dir)
+	require.NoError(t, err)
+
+	return dir
+}
+
+func TestLoadRelativeImports(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"main.mk":         `import "./lib/math" as math; import "./lib/strings.mk" as str;`,
+		"lib/math.mk":     `import "../shared" as shared; export let x = 1; let hidden = 2; export let y = 3;`,
+		"lib/strings.mk":  `import "../shared" as shared; export let join = 1; export let {split, trim: strip} = {};`,
+		"shared.mk":       `export let version = 1;`,
+		"lib/unused.mk":   `this is not valid monkey`,
+		"lib/math.mk.bak": `ignored`,
+	})
+
+	r := NewResolver(nil)
+	m, err := r.Load(filepath.Join(dir, "main.mk"))
+	require.NoError(t, err)
+
+	assert.Equal(t, filepath.Join(dir, "main.mk"), m.Path)
+	require.Len(t, m.Imports, 2)
+	require.Contains(t, m.Imports, "math")
+	require.Contains(t, m.Imports, "str")
+
+	math := m.Imports["math"]
+	assert.Equal(t, filepath.Join(dir, "lib", "math.mk"), math.Path)
+	assert.Equal(t, []string{"x", "y"}, math.Exports)
+	assert.Equal(t, []string{"join", "split", "strip"}, m.Imports["str"].Exports)
+
+	// a module imported twice is only loaded once, and the importers share it
+	assert.True(t, math.Imports["shared"] == m.Imports["str"].Imports["shared"], "shared was loaded more than once")
+	assert.Equal(t, []string{"version"}, math.Imports["shared"].Exports)
+}
+
+func TestLoadSearchPath(t *testing.T) {
+	first := writeFiles(t, map[string]string{
+		"collections/list.mk": `export let source = 1;`,
+	})
+	second := writeFiles(t, map[string]string{
+		"collections/list.mk": `export let shadowed = 1;`,
+		"text.mk":             `export let found = 1;`,
+		"main.mk":             `import "collections/list" as list; import "text" as text;`,
+	})
+
+	r := NewResolver([]string{first, second})
+	m, err := r.Load(filepath.Join(second, "main.mk"))
+	require.NoError(t, err)
+
+	// the directories are searched in order
+	assert.Equal(t, filepath.Join(first, "collections", "list.mk"), m.Imports["list"].Path)
+	assert.Equal(t, filepath.Join(second, "text.mk"), m.Imports["text"].Path)
+}
+
+func TestSearchPath(t *testing.T) {
+	old, ok := os.LookupEnv("MONKEYPATH")
+	defer func() {
+		if ok {
+			os.Setenv("MONKEYPATH", old)
+		} else {
+			os.Unsetenv("MONKEYPATH")
+		}
+	}()
+
+	os.Setenv("MONKEYPATH", "/usr/lib/monkey"+string(filepath.ListSeparator)+"/home/monkey/lib")
+	assert.Equal(t, []string{"/usr/lib/monkey", "/home/monkey/lib"}, SearchPath())
+
+	os.Setenv("MONKEYPATH", "")
+	assert.Empty(t, SearchPath())
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"cycle/a.mk":     "let x = 1;\nimport \"./b\" as b;",
+		"cycle/b.mk":     "import \"./c\" as c;",
+		"cycle/c.mk":     "\n\n  import \"./a\" as a;",
+		"self.mk":        `import "./self" as me;`,
+		"missing.mk":     "let x = 1;\n\n   import \"nowhere/mod\" as m;",
+		"duplicate.mk":   "import \"./ok\" as m;\nimport \"./ok\" as m;",
+		"ok.mk":          `export let x = 1;`,
+		"broken.mk":      `import "./syntax" as s;`,
+		"syntax.mk":      "let x 5;",
+		"directory.mk/x": ``,
+		"dir.mk":         `import "./directory" as d;`,
+	})
+
+	tests := []struct {
+		file     string
+		expected string
+	}{
+		{
+			"cycle/a.mk",
+			filepath.Join(dir, "cycle", "c.mk") + ": line 3 column 3: import cycle: " +
+				filepath.Join(dir, "cycle", "a.mk") + " -> " +
+				filepath.Join(dir, "cycle", "b.mk") + " -> " +
+				filepath.Join(dir, "cycle", "c.mk") + " -> " +
+				filepath.Join(dir, "cycle", "a.mk"),
+		},
+		{
+			"self.mk",
+			filepath.Join(dir, "self.mk") + ": line 1 column 1: import cycle: " +
+				filepath.Join(dir, "self.mk") + " -> " + filepath.Join(dir, "self.mk"),
+		},
+		{
+			"missing.mk",
+			filepath.Join(dir, "missing.mk") + `: line 3 column 4: cannot find module "nowhere/mod"`,
+		},
+		{
+			"duplicate.mk",
+			filepath.Join(dir, "duplicate.mk") + ": line 2 column 1: m is imported more than once",
+		},
+		{
+			"broken.mk",
+			filepath.Join(dir, "syntax.mk") + ": line 1 column 7: expected next token to be =, got INT instead",
+		},
+		{
+			"dir.mk",
+			filepath.Join(dir, "dir.mk") + `: line 1 column 1: cannot find module "./directory"`,
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := NewResolver([]string{dir}).Load(filepath.Join(dir, tt.file))
+		require.Error(t, err, "no error loading %s", tt.file)
+		assert.Equal(t, tt.expected, err.Error(), "Invalid error loading %s", tt.file)
+	}
+
+	_, err := NewResolver(nil).Load(filepath.Join(dir, "does-not-exist.mk"))
+	require.Error(t, err)
+	assert.IsType(t, &Error{}, err)
+}
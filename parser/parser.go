@@ -2,27 +2,140 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 
 	"github.com/kkirsche/monkey/ast"
 	"github.com/kkirsche/monkey/lexer"
 	"github.com/kkirsche/monkey/token"
 )
 
+// The precedence levels of the Monkey operators, from the loosest binding to
+// the tightest. The iota ordering is significant, as an operator with a
+// higher precedence level binds before one with a lower level.
+const (
+	_ int = iota
+	LOWEST
+	ASSIGNMENT  // = or +=
+	NULLISH     // ??
+	LOGICAL_OR  // ||
+	LOGICAL_AND // &&
+	EQUALS      // ==
+	LESSGREATER // > or <
+	SUM         // +
+	PRODUCT     // *
+	PREFIX      // -X or !X
+	CALL        // myFunction(X)
+	INDEX       // array[index] or object.field
+)
+
+// wildcard is the identifier which matches any value in a pattern without
+// binding it
+const wildcard = "_"
+
+// precedences maps each infix operator token to it's precedence level
+var precedences = map[token.Type]int{
+	token.ASSIGN:          ASSIGNMENT,
+	token.PLUS_ASSIGN:     ASSIGNMENT,
+	token.MINUS_ASSIGN:    ASSIGNMENT,
+	token.ASTERISK_ASSIGN: ASSIGNMENT,
+	token.SLASH_ASSIGN:    ASSIGNMENT,
+	token.NULLISH:         NULLISH,
+	token.OR:              LOGICAL_OR,
+	token.AND:             LOGICAL_AND,
+	token.EQ:              EQUALS,
+	token.NOT_EQ:          EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LT_EQ:           LESSGREATER,
+	token.GT_EQ:           LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.DOT:             INDEX,
+	token.QUESTION_DOT:    INDEX,
+}
+
+type (
+	// prefixParseFn is called when the associated token type is found in
+	// the prefix position, e.g. the - in -5
+	prefixParseFn func() ast.Expression
+	// infixParseFn is called when the associated token type is found in the
+	// infix position. The argument is the left side of the infix operator
+	infixParseFn func(ast.Expression) ast.Expression
+)
+
 // Parser is the structure responsible for reading tokens from the lexer and
 // generating the appropriate abstract syntax tree based on the read tokens.
 type Parser struct {
 	l *lexer.Lexer
 
 	errors    []string
+	warnings  []string
 	curToken  token.Token
 	peekToken token.Token
+
+	// blockDepth is the number of blocks enclosing the current token, which
+	// is zero at the top level of the program
+	blockDepth int
+	// loopDepth is the number of loops enclosing the current token, which
+	// allows break and continue to be rejected outside of a loop
+	loopDepth int
+
+	prefixParseFns map[token.Type]prefixParseFn
+	infixParseFns  map[token.Type]infixParseFn
 }
 
 // New creates a new Monkey programming language parser
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:        l,
+		errors:   []string{},
+		warnings: []string{},
+	}
+
+	p.prefixParseFns = make(map[token.Type]prefixParseFn)
+	p.registerPrefix(token.IDENT, p.parseIdentifier)
+	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
+	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegal)
+	p.registerPrefix(token.TRUE, p.parseBoolean)
+	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
+	p.registerPrefix(token.BANG, p.parsePrefixExpression)
+	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
+	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
+	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
+	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
+	p.registerPrefix(token.MACRO, p.parseMacroLiteral)
+	p.registerPrefix(token.MATCH, p.parseMatchExpression)
+
+	p.infixParseFns = make(map[token.Type]infixParseFn)
+	for _, t := range []token.Type{
+		token.PLUS, token.MINUS, token.SLASH, token.ASTERISK,
+		token.EQ, token.NOT_EQ,
+		token.LT, token.GT, token.LT_EQ, token.GT_EQ,
+	} {
+		p.registerInfix(t, p.parseInfixExpression)
+	}
+	p.registerInfix(token.AND, p.parseLogicalExpression)
+	p.registerInfix(token.OR, p.parseLogicalExpression)
+	p.registerInfix(token.NULLISH, p.parseLogicalExpression)
+	p.registerInfix(token.LPAREN, p.parseCallExpression)
+	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseFieldExpression)
+	p.registerInfix(token.QUESTION_DOT, p.parseOptionalExpression)
+	for _, t := range []token.Type{
+		token.ASSIGN, token.PLUS_ASSIGN, token.MINUS_ASSIGN,
+		token.ASTERISK_ASSIGN, token.SLASH_ASSIGN,
+	} {
+		p.registerInfix(t, p.parseAssignExpression)
 	}
 
 	// Read two tokens, so curToken and peekToken are both set
@@ -32,9 +145,26 @@ func New(l *lexer.Lexer) *Parser {
 	return p
 }
 
-// Errors is a getter method allowing clients to read the parser errors
+// Errors is a getter method allowing clients to read the parser errors. This
+// includes the reasons for any illegal tokens produced by the lexer, which are
+// listed first.
 func (p *Parser) Errors() []string {
-	return p.errors
+	lexerErrors := p.l.Errors()
+	if len(lexerErrors) == 0 {
+		return p.errors
+	}
+
+	errors := make([]string, 0, len(lexerErrors)+len(p.errors))
+	errors = append(errors, lexerErrors...)
+	return append(errors, p.errors...)
+}
+
+// Warnings is a getter method allowing clients to read the parser warnings.
+// Unlike errors, warnings describe programs which are valid, but which are
+// likely to be mistakes, such as a match on a boolean which only handles one
+// of true or false
+func (p *Parser) Warnings() []string {
+	return p.warnings
 }
 
 func (p *Parser) nextToken() {
@@ -58,18 +188,60 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
-// parseStatement is used to parse each statement within the input. In the case
-// of an unsupported or unknown token, ignore it by returning nil. This allows
-// the parser to "eat" unknown or currently unsupported inputs
+// parseStatement is used to parse each statement within the input. Anything
+// which is not introduced by a statement keyword is parsed as an expression
+// statement. A statement which failed to parse is returned as a plain nil,
+// rather than a nil pointer held in the interface, so that callers can drop
+// it by comparing against nil
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.LET:
-		return p.parseLetStatement()
+		if stmt := p.parseLetStatement(); stmt != nil {
+			return stmt
+		}
 	case token.RETURN:
-		return p.parseReturnStatement()
+		if stmt := p.parseReturnStatement(); stmt != nil {
+			return stmt
+		}
+	case token.THROW:
+		if stmt := p.parseThrowStatement(); stmt != nil {
+			return stmt
+		}
+	case token.TRY:
+		if stmt := p.parseTryStatement(); stmt != nil {
+			return stmt
+		}
+	case token.IMPORT:
+		if stmt := p.parseImportStatement(); stmt != nil {
+			return stmt
+		}
+	case token.EXPORT:
+		if stmt := p.parseExportStatement(); stmt != nil {
+			return stmt
+		}
+	case token.WHILE:
+		if stmt := p.parseWhileStatement(); stmt != nil {
+			return stmt
+		}
+	case token.FOR:
+		if stmt := p.parseForInStatement(); stmt != nil {
+			return stmt
+		}
+	case token.BREAK:
+		if stmt := p.parseBreakStatement(); stmt != nil {
+			return stmt
+		}
+	case token.CONTINUE:
+		if stmt := p.parseContinueStatement(); stmt != nil {
+			return stmt
+		}
 	default:
-		return nil
+		if stmt := p.parseExpressionStatement(); stmt != nil {
+			return stmt
+		}
 	}
+
+	return nil
 }
 
 // parseLetStatement is called when a let token has been found during the
@@ -77,15 +249,23 @@ func (p *Parser) parseStatement() ast.Statement {
 func (p *Parser) parseLetStatement() *ast.LetStatement {
 	stmt := &ast.LetStatement{Token: p.curToken}
 
-	// enforce that the next token is an identifier
 	// let identifier = expression;
-	if !p.expectPeek(token.IDENT) {
+	// let [identifier, ...identifier] = expression;
+	// let {identifier, identifier: pattern} = expression;
+	p.nextToken()
+	stmt.Name = p.parsePattern()
+	if stmt.Name == nil {
 		return nil
 	}
 
-	stmt.Name = &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
+	// the type annotation is optional
+	// let identifier: type = expression;
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		stmt.Type = p.parseTypeExpr()
+		if stmt.Type == nil {
+			return nil
+		}
 	}
 
 	// enforce that the next token is an assignment operator
@@ -94,14 +274,200 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
-	// TODO: We're skipping the expressions until we encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	// the semicolon is optional, which makes expressions such as `let x = 5`
+	// easier to type into the REPL
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
+// parsePattern parses the target of a binding, beginning at the current
+// token. This is either a single identifier, or an array or hash pattern which
+// destructures the value being bound. A lone underscore is lexed as an
+// ordinary identifier, so that it remains usable as a name in expressions, but
+// is a wildcard wherever a pattern is expected
+func (p *Parser) parsePattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.IDENT:
+		if p.curToken.Literal == wildcard {
+			return &ast.WildcardPattern{Token: p.curToken}
+		}
+		return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	case token.LBRACKET:
+		return p.parseArrayPattern(p.parsePattern)
+	case token.LBRACE:
+		return p.parseHashPattern(p.parsePattern)
+	}
+
+	msg := fmt.Sprintf("line %d column %d: expected an identifier, [ or { to begin a binding, got %s instead", p.curToken.Line, p.curToken.Column, p.curToken.Type)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseMatchPattern parses the pattern of a match arm, beginning at the
+// current token. In addition to everything allowed in a binding, the pattern
+// of a match arm may contain literals which the value must be equal to
+func (p *Parser) parseMatchPattern() ast.Pattern {
+	switch p.curToken.Type {
+	case token.INT, token.FLOAT, token.STRING, token.TRUE, token.FALSE, token.NULL:
+		pattern := &ast.LiteralPattern{Token: p.curToken}
+		pattern.Value = p.prefixParseFns[p.curToken.Type]()
+		if pattern.Value == nil {
+			return nil
+		}
+		return pattern
+	case token.MINUS:
+		if !p.peekTokenIs(token.INT) && !p.peekTokenIs(token.FLOAT) {
+			break
+		}
+		pattern := &ast.LiteralPattern{Token: p.curToken}
+		negation := &ast.PrefixExpression{Token: p.curToken, Operator: p.curToken.Literal}
+		p.nextToken()
+		negation.Right = p.prefixParseFns[p.curToken.Type]()
+		if negation.Right == nil {
+			return nil
+		}
+		pattern.Value = negation
+		return pattern
+	case token.IDENT:
+		return p.parsePattern()
+	case token.LBRACKET:
+		return p.parseArrayPattern(p.parseMatchPattern)
+	case token.LBRACE:
+		return p.parseHashPattern(p.parseMatchPattern)
+	}
+
+	msg := fmt.Sprintf("line %d column %d: expected a pattern, got %s instead", p.curToken.Line, p.curToken.Column, p.curToken.Type)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseArrayPattern parses an array destructuring pattern, using element to
+// parse each of the patterns within it
+// [pattern, pattern, ...identifier]
+func (p *Parser) parseArrayPattern(element func() ast.Pattern) ast.Pattern {
+	pattern := &ast.ArrayPattern{Token: p.curToken, Elements: []ast.Pattern{}}
+
+	for !p.peekTokenIs(token.RBRACKET) {
+		p.nextToken()
+
+		var el ast.Pattern
+		if p.curTokenIs(token.SPREAD) {
+			rest := p.parseRestElement(token.RBRACKET)
+			if rest == nil {
+				return nil
+			}
+			el = rest
+		} else {
+			el = element()
+			if el == nil {
+				return nil
+			}
+		}
+		pattern.Elements = append(pattern.Elements, el)
+
+		// a trailing comma before the closing bracket is allowed
+		if !p.peekTokenIs(token.RBRACKET) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseHashPattern parses a hash destructuring pattern, using value to parse
+// the pattern of each key which has one
+// {identifier, identifier: pattern, ...identifier}
+func (p *Parser) parseHashPattern(value func() ast.Pattern) ast.Pattern {
+	pattern := &ast.HashPattern{Token: p.curToken, Pairs: []ast.HashPatternPair{}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		if p.curTokenIs(token.SPREAD) {
+			pattern.Rest = p.parseRestElement(token.RBRACE)
+			if pattern.Rest == nil {
+				return nil
+			}
+		} else {
+			if !p.curTokenIs(token.IDENT) {
+				msg := fmt.Sprintf("line %d column %d: expected a key in the hash pattern, got %s instead", p.curToken.Line, p.curToken.Column, p.curToken.Type)
+				p.errors = append(p.errors, msg)
+				return nil
+			}
+
+			key := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			pair := ast.HashPatternPair{Key: key, Value: key}
+
+			// without a pattern, the value is bound to the name of the key
+			if p.peekTokenIs(token.COLON) {
+				p.nextToken()
+				p.nextToken()
+				pair.Value = value()
+				if pair.Value == nil {
+					return nil
+				}
+			}
+			pattern.Pairs = append(pattern.Pairs, pair)
+		}
+
+		// a trailing comma before the closing brace is allowed
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return pattern
+}
+
+// parseRestElement parses the rest element of an array or hash pattern, which
+// must be the last part of the pattern before the end token
+// ...identifier
+func (p *Parser) parseRestElement(end token.Type) *ast.RestElement {
+	rest := &ast.RestElement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	rest.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+	}
+	if !p.peekTokenIs(end) {
+		msg := fmt.Sprintf("line %d column %d: a rest element must be the last part of a pattern", rest.Token.Line, rest.Token.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return rest
+}
+
+// parseTypeExpr is called when the current token introduces a type
+// annotation, such as the colon in `let x: int` or the arrow in `fn() -> int`,
+// and reads the type name which follows it
+func (p *Parser) parseTypeExpr() *ast.TypeExpr {
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	return &ast.TypeExpr{Token: p.curToken, Name: p.curToken.Literal}
+}
+
 // parseReturnStatement is called when a return token has been found during the
 // parseStatement branch decision
 func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
@@ -109,24 +475,998 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	p.nextToken()
 
-	// TODO: We're skipping the expressions until we encounter a semicolon
-	for !p.curTokenIs(token.SEMICOLON) {
+	stmt.ReturnValue = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
-// curTokenIs is used to check that the current token is what we require it to
-// be to continue parsing, or returns false
-func (p *Parser) curTokenIs(t token.Type) bool {
-	return p.curToken.Type == t
+// parseImportStatement is called when an import token has been found during
+// the parseStatement branch decision
+// import "path/to/mod" as identifier;
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.requireTopLevel() {
+		return nil
+	}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.AS) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
 }
 
-// peekTokenIs is used to check that the peeked token is what we require it to
-// be to continue parsing, or returns false
-func (p *Parser) peekTokenIs(t token.Type) bool {
-	return p.peekToken.Type == t
+// parseExportStatement is called when an export token has been found during
+// the parseStatement branch decision
+// export let identifier = expression;
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if !p.requireTopLevel() {
+		return nil
+	}
+
+	if !p.expectPeek(token.LET) {
+		return nil
+	}
+	stmt.Statement = p.parseLetStatement()
+	if stmt.Statement == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// requireTopLevel reports an error and returns false when the current token
+// is inside of a block, as a module's imports and exports must be known
+// without running any of it's code
+func (p *Parser) requireTopLevel() bool {
+	if p.blockDepth == 0 {
+		return true
+	}
+
+	msg := fmt.Sprintf("line %d column %d: %s is only allowed at the top level of a module", p.curToken.Line, p.curToken.Column, p.curToken.Literal)
+	p.errors = append(p.errors, msg)
+	return false
+}
+
+// parseWhileStatement is called when a while token has been found during the
+// parseStatement branch decision
+// while (expression) { ... }
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || stmt.Condition == nil {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseForInStatement is called when a for token has been found during the
+// parseStatement branch decision
+// for (identifier in expression) { ... }
+func (p *Parser) parseForInStatement() *ast.ForInStatement {
+	stmt := &ast.ForInStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || stmt.Iterable == nil {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+	if stmt.Body == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseLoopBody parses the block following the peeked token as the body of a
+// loop, in which break and continue are allowed
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	return p.parseBlockStatement()
+}
+
+// parseBreakStatement is called when a break token has been found during the
+// parseStatement branch decision
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	if !p.requireLoop() {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseContinueStatement is called when a continue token has been found
+// during the parseStatement branch decision
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if !p.requireLoop() {
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// requireLoop reports an error and returns false when the current token is
+// not inside of a loop body
+func (p *Parser) requireLoop() bool {
+	if p.loopDepth > 0 {
+		return true
+	}
+
+	msg := fmt.Sprintf("line %d column %d: %s is not inside a loop", p.curToken.Line, p.curToken.Column, p.curToken.Literal)
+	p.errors = append(p.errors, msg)
+	return false
+}
+
+// parseThrowStatement is called when a throw token has been found during the
+// parseStatement branch decision
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+// parseTryStatement is called when a try token has been found during the
+// parseStatement branch decision
+// try { ... } catch (identifier) { ... } finally { ... }
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LPAREN) {
+			return nil
+		}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.CatchParameter = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		msg := fmt.Sprintf("line %d column %d: expected catch or finally after try block", stmt.Token.Line, stmt.Token.Column)
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	return stmt
+}
+
+// parseBlockStatement parses the statements between a pair of braces. The
+// current token is expected to be the opening brace, and the closing brace is
+// the current token once the block has been parsed.
+func (p *Parser) parseBlockStatement() *ast.BlockStatement {
+	block := &ast.BlockStatement{Token: p.curToken}
+	block.Statements = []ast.Statement{}
+
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	p.nextToken()
+
+	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
+		stmt := p.parseStatement()
+		if stmt != nil {
+			block.Statements = append(block.Statements, stmt)
+		}
+		p.nextToken()
+	}
+
+	if !p.curTokenIs(token.RBRACE) {
+		msg := fmt.Sprintf("line %d column %d: expected } to close the block opened at line %d column %d", p.curToken.Line, p.curToken.Column, block.Token.Line, block.Token.Column)
+		p.errors = append(p.errors, msg)
+	}
+
+	return block
+}
+
+// parseExpressionStatement is called for any statement which does not begin
+// with a statement keyword, such as `x + 10;`
+func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
+	stmt := &ast.ExpressionStatement{Token: p.curToken}
+
+	stmt.Expression = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	if stmt.Expression == nil {
+		return nil
+	}
+
+	return stmt
+}
+
+// parseExpression is the heart of the Pratt parser. It parses the prefix
+// expression at the current token, then keeps folding it into the left side
+// of infix expressions for as long as the next operator binds more tightly
+// than the precedence we were called with
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+	prefix := p.prefixParseFns[p.curToken.Type]
+	if prefix == nil {
+		p.noPrefixParseFnError(p.curToken)
+		return nil
+	}
+	leftExp := prefix()
+
+	// an operand which failed to parse has already been reported, and no
+	// operator may be applied to it
+	for leftExp != nil && !p.peekTokenIs(token.SEMICOLON) && precedence < p.peekPrecedence() {
+		infix := p.infixParseFns[p.peekToken.Type]
+		if infix == nil {
+			return leftExp
+		}
+
+		p.nextToken()
+
+		leftExp = infix(leftExp)
+	}
+
+	return leftExp
+}
+
+func (p *Parser) parseIdentifier() ast.Expression {
+	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// parseIntegerLiteral decodes an integer literal in any of it's supported
+// bases. The lexer has already validated the syntax of the literal. Literals
+// which are too large for an int64 are decoded into a big.Int instead.
+func (p *Parser) parseIntegerLiteral() ast.Expression {
+	lit := &ast.IntegerLiteral{Token: p.curToken}
+
+	digits := strings.Replace(p.curToken.Literal, "_", "", -1)
+	base := 10
+	if len(digits) > 2 && digits[0] == '0' {
+		switch digits[1] {
+		case 'x', 'X':
+			base = 16
+		case 'o', 'O':
+			base = 8
+		case 'b', 'B':
+			base = 2
+		}
+		if base != 10 {
+			digits = digits[2:]
+		}
+	}
+
+	value, err := strconv.ParseInt(digits, base, 64)
+	if err == nil {
+		lit.Value = value
+		return lit
+	}
+
+	if err.(*strconv.NumError).Err == strconv.ErrRange {
+		if n, ok := new(big.Int).SetString(digits, base); ok {
+			lit.Big = n
+			return lit
+		}
+	}
+
+	msg := fmt.Sprintf("line %d column %d: could not parse %q as integer", p.curToken.Line, p.curToken.Column, p.curToken.Literal)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// parseFloatLiteral decodes a floating point literal, reporting values which
+// are too large to be represented by a float64
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(strings.Replace(p.curToken.Literal, "_", "", -1), 64)
+	if err != nil {
+		msg := fmt.Sprintf("line %d column %d: could not parse %q as float", p.curToken.Line, p.curToken.Column, p.curToken.Literal)
+		if err.(*strconv.NumError).Err == strconv.ErrRange {
+			msg = fmt.Sprintf("line %d column %d: float literal %q overflows float64", p.curToken.Line, p.curToken.Column, p.curToken.Literal)
+		}
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseStringLiteral() ast.Expression {
+	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+}
+
+// parseIllegal is called for an illegal token. The lexer has already recorded
+// why the token is illegal, and that reason is included in Errors, so there is
+// nothing left to report
+func (p *Parser) parseIllegal() ast.Expression {
+	return nil
+}
+
+func (p *Parser) parseBoolean() ast.Expression {
+	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
+// parseGroupedExpression parses an expression wrapped in parentheses. As the
+// inner expression is parsed from the lowest precedence, the parentheses
+// override the precedence of the surrounding operators
+func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.nextToken()
+
+	exp := p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return exp
+}
+
+func (p *Parser) parsePrefixExpression() ast.Expression {
+	expression := &ast.PrefixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+	}
+
+	p.nextToken()
+
+	expression.Right = p.parseExpression(PREFIX)
+	if expression.Right == nil {
+		return nil
+	}
+
+	return expression
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	expression := &ast.InfixExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+	if expression.Right == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// parseLogicalExpression parses the short-circuiting &&, || and ?? operators.
+// They are parsed just like any other left associative infix operator, but
+// produce their own node type so that the right side can be skipped
+func (p *Parser) parseLogicalExpression(left ast.Expression) ast.Expression {
+	expression := &ast.LogicalExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Left:     left,
+	}
+
+	precedence := p.curPrecedence()
+	p.nextToken()
+	expression.Right = p.parseExpression(precedence)
+	if expression.Right == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// parseAssignExpression parses an assignment to the expression on it's left.
+// Assignment is right associative, so `a = b = 0` assigns zero to b before
+// assigning the result to a. Only identifiers, index expressions and field
+// expressions may be assigned to, and optional accesses such as `a?.b` may not.
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+
+	// an optional access is always wrapped in an *ast.OptionalChain, so it
+	// is rejected along with any other expression
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.FieldExpression:
+	case nil:
+		// the target failed to parse, and the reason has been reported
+		return nil
+	default:
+		return p.invalidAssignTarget(target)
+	}
+
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGNMENT - 1)
+	if expression.Value == nil {
+		return nil
+	}
+
+	return expression
+}
+
+// invalidAssignTarget reports that the target of the current assignment token
+// cannot be assigned to. The target is described by it's token, rather than
+// printed in full, as it may be a partially built node
+func (p *Parser) invalidAssignTarget(target ast.Expression) ast.Expression {
+	msg := fmt.Sprintf("line %d column %d: invalid assignment target %q", p.curToken.Line, p.curToken.Column, target.TokenLiteral())
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+// curTokenIs is used to check that the current token is what we require it to
+// be to continue parsing, or returns false
+func (p *Parser) curTokenIs(t token.Type) bool {
+	return p.curToken.Type == t
+}
+
+// peekTokenIs is used to check that the peeked token is what we require it to
+// be to continue parsing, or returns false
+func (p *Parser) peekTokenIs(t token.Type) bool {
+	return p.peekToken.Type == t
+}
+
+func (p *Parser) parseArrayLiteral() ast.Expression {
+	array := &ast.ArrayLiteral{Token: p.curToken}
+
+	array.Elements = p.parseExpressionList(token.RBRACKET)
+	if array.Elements == nil {
+		return nil
+	}
+
+	return array
+}
+
+// parseHashLiteral parses a hash literal, such as `{"name": "x", 1: true}`.
+// This is called whenever a left brace appears where an expression is
+// expected, including at the start of a statement. Braces which open a block
+// are only valid where the grammar requires a block, and are parsed directly
+// there rather than through the expression parser, so the two can never be
+// confused.
+func (p *Parser) parseHashLiteral() ast.Expression {
+	hash := &ast.HashLiteral{Token: p.curToken, Pairs: []ast.HashPair{}}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+		key := p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+
+		if key == nil || value == nil {
+			return nil
+		}
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
+
+		// a trailing comma before the closing brace is allowed
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	return hash
+}
+
+func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
+	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
+
+	p.nextToken()
+	exp.Index = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RBRACKET) || exp.Index == nil {
+		return nil
+	}
+
+	return exp
+}
+
+// parseFieldExpression parses access to a named field of the expression on
+// it's left, such as `person.name`
+func (p *Parser) parseFieldExpression(object ast.Expression) ast.Expression {
+	exp := &ast.FieldExpression{Token: p.curToken, Object: object}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Field = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
+
+// parseOptionalExpression parses an optional chain, which begins with the
+// optional access to a field or index of the expression on it's left. Every
+// field access, index access and call which directly follows is part of the
+// same chain, and is skipped when an optional link finds null
+// object?.field.field
+// object?.[index](arguments)
+func (p *Parser) parseOptionalExpression(left ast.Expression) ast.Expression {
+	chain := &ast.OptionalChain{Token: p.curToken}
+
+	exp := p.parseOptionalLink(left)
+	for exp != nil && p.isChainLink(p.peekToken.Type) {
+		p.nextToken()
+		if p.curTokenIs(token.QUESTION_DOT) {
+			exp = p.parseOptionalLink(exp)
+		} else {
+			exp = p.infixParseFns[p.curToken.Type](exp)
+		}
+	}
+
+	if exp == nil {
+		return nil
+	}
+
+	chain.Expression = exp
+	return chain
+}
+
+// isChainLink reports whether a token of type t continues an optional chain
+func (p *Parser) isChainLink(t token.Type) bool {
+	switch t {
+	case token.DOT, token.LBRACKET, token.LPAREN, token.QUESTION_DOT:
+		return true
+	}
+	return false
+}
+
+// parseOptionalLink parses a single optional access to a field or index of
+// the expression on it's left
+// object?.field
+// object?.[index]
+func (p *Parser) parseOptionalLink(left ast.Expression) ast.Expression {
+	tok := p.curToken
+
+	switch {
+	case p.peekTokenIs(token.IDENT):
+		p.nextToken()
+		field := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+		return &ast.FieldExpression{Token: tok, Object: left, Field: field, Optional: true}
+	case p.peekTokenIs(token.LBRACKET):
+		p.nextToken()
+		exp := &ast.IndexExpression{Token: tok, Left: left, Optional: true}
+
+		p.nextToken()
+		exp.Index = p.parseExpression(LOWEST)
+
+		if !p.expectPeek(token.RBRACKET) || exp.Index == nil {
+			return nil
+		}
+		return exp
+	}
+
+	msg := fmt.Sprintf("line %d column %d: expected a field name or [ after ?., got %s instead", p.peekToken.Line, p.peekToken.Column, p.peekToken.Type)
+	p.errors = append(p.errors, msg)
+	return nil
+}
+
+func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
+	exp := &ast.CallExpression{Token: p.curToken, Function: function}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	if exp.Arguments == nil {
+		return nil
+	}
+	return exp
+}
+
+// parseMatchExpression parses a match expression, each arm of which is
+// separated by a comma
+// match (expression) { pattern => expression, pattern if expression => expression }
+func (p *Parser) parseMatchExpression() ast.Expression {
+	exp := &ast.MatchExpression{Token: p.curToken, Arms: []ast.MatchArm{}}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	exp.Subject = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) || exp.Subject == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	for !p.peekTokenIs(token.RBRACE) {
+		p.nextToken()
+
+		arm := ast.MatchArm{Pattern: p.parseMatchPattern()}
+		if arm.Pattern == nil {
+			return nil
+		}
+
+		if p.peekTokenIs(token.IF) {
+			p.nextToken()
+			p.nextToken()
+			arm.Guard = p.parseExpression(LOWEST)
+			if arm.Guard == nil {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.FATARROW) {
+			return nil
+		}
+
+		p.nextToken()
+		arm.Body = p.parseExpression(LOWEST)
+		if arm.Body == nil {
+			return nil
+		}
+
+		exp.Arms = append(exp.Arms, arm)
+
+		// a trailing comma before the closing brace is allowed
+		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.RBRACE) {
+		return nil
+	}
+
+	p.checkBooleanMatch(exp)
+
+	return exp
+}
+
+// checkBooleanMatch warns when every literal in the arms of a match expression
+// is a boolean, but only one of true or false is handled and there is no
+// catch-all arm. Only booleans can be checked, as the type of the subject is
+// otherwise unknown until the program runs
+func (p *Parser) checkBooleanMatch(exp *ast.MatchExpression) {
+	var boolean, matchesTrue, matchesFalse bool
+
+	for _, arm := range exp.Arms {
+		switch pattern := arm.Pattern.(type) {
+		case *ast.WildcardPattern, *ast.Identifier:
+			if arm.Guard == nil {
+				return
+			}
+		case *ast.LiteralPattern:
+			b, ok := pattern.Value.(*ast.Boolean)
+			if !ok {
+				return
+			}
+			boolean = true
+			if arm.Guard == nil {
+				matchesTrue = matchesTrue || b.Value
+				matchesFalse = matchesFalse || !b.Value
+			}
+		default:
+			return
+		}
+	}
+
+	if !boolean || matchesTrue && matchesFalse {
+		return
+	}
+
+	missing := []string{}
+	if !matchesTrue {
+		missing = append(missing, "true")
+	}
+	if !matchesFalse {
+		missing = append(missing, "false")
+	}
+
+	msg := fmt.Sprintf("line %d column %d: match is not exhaustive, %s is not handled", exp.Token.Line, exp.Token.Column, strings.Join(missing, " or "))
+	p.warnings = append(p.warnings, msg)
+}
+
+// parseFunctionLiteral parses a function definition, the parameters and
+// return value of which may be annotated with types
+// fn(identifier: type, identifier) -> type { ... }
+func (p *Parser) parseFunctionLiteral() ast.Expression {
+	lit := &ast.FunctionLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseFunctionParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	if p.peekTokenIs(token.ARROW) {
+		p.nextToken()
+		lit.ReturnType = p.parseTypeExpr()
+		if lit.ReturnType == nil {
+			return nil
+		}
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	// a function body is not part of any loop the function is defined
+	// within, so break and continue may not escape it
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+
+	return lit
+}
+
+// parseFunctionParameters parses the parameter list of a function literal,
+// each of which may be annotated with a type, up to and including the closing
+// parenthesis
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	parameters := []*ast.Parameter{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return parameters
+	}
+
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		parameter := &ast.Parameter{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+
+		if p.peekTokenIs(token.COLON) {
+			p.nextToken()
+			parameter.Type = p.parseTypeExpr()
+			if parameter.Type == nil {
+				return nil
+			}
+		}
+		parameters = append(parameters, parameter)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return parameters
+}
+
+// parseMacroLiteral parses a macro definition
+// macro(identifier, identifier) { ... }
+func (p *Parser) parseMacroLiteral() ast.Expression {
+	lit := &ast.MacroLiteral{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	lit.Parameters = p.parseParameters()
+	if lit.Parameters == nil {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	// a macro body is not part of any loop the macro is defined within, so
+	// break and continue may not escape it
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
+	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
+
+	return lit
+}
+
+// parseParameters parses a comma separated list of parameter names, up to and
+// including the closing parenthesis. The current token is expected to be the
+// opening parenthesis.
+func (p *Parser) parseParameters() []*ast.Identifier {
+	identifiers := []*ast.Identifier{}
+
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return identifiers
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		identifiers = append(identifiers, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	return identifiers
+}
+
+// parseExpressionList parses a comma separated list of expressions, such as
+// the elements of an array literal, up to and including the end token. The
+// current token is expected to be the token which opens the list. A trailing
+// comma before the end token is allowed.
+func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
+	list := []ast.Expression{}
+
+	if p.peekTokenIs(end) {
+		p.nextToken()
+		return list
+	}
+
+	p.nextToken()
+	list = append(list, p.parseExpression(LOWEST))
+
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if p.peekTokenIs(end) {
+			break
+		}
+		p.nextToken()
+		list = append(list, p.parseExpression(LOWEST))
+	}
+
+	if !p.expectPeek(end) {
+		return nil
+	}
+
+	for _, exp := range list {
+		if exp == nil {
+			return nil
+		}
+	}
+
+	return list
+}
+
+// peekPrecedence returns the precedence level of the peeked token, or LOWEST
+// when it is not an infix operator
+func (p *Parser) peekPrecedence() int {
+	if p, ok := precedences[p.peekToken.Type]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+// curPrecedence returns the precedence level of the current token, or LOWEST
+// when it is not an infix operator
+func (p *Parser) curPrecedence() int {
+	if p, ok := precedences[p.curToken.Type]; ok {
+		return p
+	}
+
+	return LOWEST
+}
+
+func (p *Parser) registerPrefix(tokenType token.Type, fn prefixParseFn) {
+	p.prefixParseFns[tokenType] = fn
+}
+
+func (p *Parser) registerInfix(tokenType token.Type, fn infixParseFn) {
+	p.infixParseFns[tokenType] = fn
+}
+
+func (p *Parser) noPrefixParseFnError(t token.Token) {
+	msg := fmt.Sprintf("line %d column %d: no prefix parse function for %s found", t.Line, t.Column, t.Type)
+	p.errors = append(p.errors, msg)
 }
 
 func (p *Parser) peekError(t token.Type) {
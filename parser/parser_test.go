@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"math/big"
+	"math/rand"
+	"strings"
 	"testing"
 
 	"github.com/kkirsche/monkey/ast"
@@ -53,17 +56,65 @@ func testLetStatement(t *testing.T, s ast.Statement, name string) bool {
 		return false
 	}
 
-	if !assert.Equalf(t, name, letStmt.Name.Value, "letStmt.Name.Value not '%s'. got=%s", name, letStmt.Name.Value) {
+	ident, ok := letStmt.Name.(*ast.Identifier)
+	if !ok {
+		t.Errorf("letStmt.Name not *ast.Identifier. got=%T", letStmt.Name)
+		return false
+	}
+
+	if !assert.Equalf(t, name, ident.Value, "letStmt.Name.Value not '%s'. got=%s", name, ident.Value) {
 		return false
 	}
 
-	if !assert.Equalf(t, name, letStmt.Name.TokenLiteral(), "letStmt.Name.TokenLiteral() not '%s'. got=%s", name, letStmt.Name.TokenLiteral()) {
+	if !assert.Equalf(t, name, ident.TokenLiteral(), "letStmt.Name.TokenLiteral() not '%s'. got=%s", name, ident.TokenLiteral()) {
 		return false
 	}
 
 	return true
 }
 
+func TestLetStatementTypeAnnotations(t *testing.T) {
+	input := `
+let x: int = 5;
+let y = 10;
+let name: string = 838383;
+`
+
+	l := lexer.New(input)
+	p := New(l)
+
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	require.NotNil(t, program, "ParseProgram() returned nil")
+	require.Lenf(t, program.Statements, 3, "program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+
+	tests := []struct {
+		identifier string
+		typeName   string
+	}{
+		{"x", "int"},
+		{"y", ""},
+		{"name", "string"},
+	}
+
+	for i, tt := range tests {
+		stmt := program.Statements[i]
+		if !testLetStatement(t, stmt, tt.identifier) {
+			return
+		}
+
+		letStmt := stmt.(*ast.LetStatement)
+		if tt.typeName == "" {
+			assert.Nilf(t, letStmt.Type, "letStmt.Type not nil. got=%+v", letStmt.Type)
+			continue
+		}
+
+		require.NotNil(t, letStmt.Type, "letStmt.Type is nil")
+		assert.Equalf(t, tt.typeName, letStmt.Type.Name, "letStmt.Type.Name not '%s'. got=%s", tt.typeName, letStmt.Type.Name)
+		assert.Equalf(t, tt.typeName, letStmt.Type.TokenLiteral(), "letStmt.Type.TokenLiteral() not '%s'. got=%s", tt.typeName, letStmt.Type.TokenLiteral())
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if assert.Len(t, errors, 0) {
@@ -77,6 +128,67 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	t.FailNow()
 }
 
+func TestDestructuringLetStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		pattern  string
+		bindings []string
+	}{
+		{`let [a, b] = xs;`, "[a, b]", []string{"a", "b"}},
+		{`let [first, ...rest] = xs;`, "[first, ...rest]", []string{"first", "rest"}},
+		{`let [] = xs;`, "[]", nil},
+		{`let [x, [y, z],] = xs;`, "[x, [y, z]]", []string{"x", "y", "z"}},
+		{`let {name, age} = person;`, "{name, age}", []string{"name", "age"}},
+		{`let {name: n, ...others} = person;`, "{name: n, ...others}", []string{"n", "others"}},
+		{`let {pos: [x, y], size: {w}} = shape;`, "{pos: [x, y], size: {w}}", []string{"x", "y", "w"}},
+		{`let [{id}, ...more,] = items`, "[{id}, ...more]", []string{"id", "more"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		require.Truef(t, ok, "stmt not *ast.LetStatement. got=%T", program.Statements[0])
+
+		assert.Equal(t, tt.pattern, stmt.Name.String())
+
+		var bindings []string
+		for _, ident := range ast.Bindings(stmt.Name) {
+			bindings = append(bindings, ident.Value)
+		}
+		assert.Equal(t, tt.bindings, bindings)
+	}
+}
+
+func TestDestructuringLetErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let 5 = x;`, "line 1 column 5: expected an identifier, [ or { to begin a binding, got INT instead"},
+		{`let [a, 1] = x;`, "line 1 column 9: expected an identifier, [ or { to begin a binding, got INT instead"},
+		{`let [...rest, last] = x;`, "line 1 column 6: a rest element must be the last part of a pattern"},
+		{`let {...rest, a} = x;`, "line 1 column 6: a rest element must be the last part of a pattern"},
+		{`let [...] = x;`, "line 1 column 9: expected next token to be IDENT, got ] instead"},
+		{`let {"name"} = x;`, "line 1 column 6: expected a key in the hash pattern, got STRING instead"},
+		{`let [a b] = x;`, "line 1 column 8: expected next token to be ,, got IDENT instead"},
+		{`let {a: } = x;`, "line 1 column 9: expected an identifier, [ or { to begin a binding, got } instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	input := `
 return 5;
@@ -102,3 +214,1134 @@ return 993322;
 		assert.Equalf(t, "return", returnStmt.TokenLiteral(), "returnStmt.TokenLiteral not 'return', got %q", returnStmt.TokenLiteral())
 	}
 }
+
+func TestParsingInfixExpressions(t *testing.T) {
+	tests := []struct {
+		input      string
+		leftValue  int64
+		operator   string
+		rightValue int64
+	}{
+		{"5 + 5;", 5, "+", 5},
+		{"5 - 5;", 5, "-", 5},
+		{"5 * 5;", 5, "*", 5},
+		{"5 / 5;", 5, "/", 5},
+		{"5 > 5;", 5, ">", 5},
+		{"5 < 5;", 5, "<", 5},
+		{"5 >= 5;", 5, ">=", 5},
+		{"5 <= 5;", 5, "<=", 5},
+		{"5 == 5;", 5, "==", 5},
+		{"5 != 5;", 5, "!=", 5},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		require.Truef(t, ok, "program.Statements[0] is not *ast.ExpressionStatement. got=%T", program.Statements[0])
+
+		exp, ok := stmt.Expression.(*ast.InfixExpression)
+		require.Truef(t, ok, "exp is not *ast.InfixExpression. got=%T", stmt.Expression)
+		testIntegerLiteral(t, exp.Left, tt.leftValue)
+		assert.Equalf(t, tt.operator, exp.Operator, "exp.Operator is not '%s'. got=%s", tt.operator, exp.Operator)
+		testIntegerLiteral(t, exp.Right, tt.rightValue)
+	}
+}
+
+func TestParsingLogicalExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		operator string
+	}{
+		{"a && b;", "&&"},
+		{"a || b;", "||"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		require.Truef(t, ok, "program.Statements[0] is not *ast.ExpressionStatement. got=%T", program.Statements[0])
+
+		exp, ok := stmt.Expression.(*ast.LogicalExpression)
+		require.Truef(t, ok, "exp is not *ast.LogicalExpression. got=%T", stmt.Expression)
+		assert.Equalf(t, "a", exp.Left.String(), "exp.Left is not 'a'. got=%s", exp.Left)
+		assert.Equalf(t, tt.operator, exp.Operator, "exp.Operator is not '%s'. got=%s", tt.operator, exp.Operator)
+		assert.Equalf(t, "b", exp.Right.String(), "exp.Right is not 'b'. got=%s", exp.Right)
+	}
+}
+
+func TestOperatorPrecedenceParsing(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"-a * b", "((-a) * b)"},
+		{"!-a", "(!(-a))"},
+		{"a + b - c", "((a + b) - c)"},
+		{"a * b / c", "((a * b) / c)"},
+		{"a + b * c", "(a + (b * c))"},
+		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
+		{"5 >= 4 != 3 <= 4", "((5 >= 4) != (3 <= 4))"},
+		{"1 + (2 + 3) + 4", "((1 + (2 + 3)) + 4)"},
+		{"-(5 + 5)", "(-(5 + 5))"},
+		{"a || b && c", "(a || (b && c))"},
+		{"a && b || c", "((a && b) || c)"},
+		{"a && b && c", "((a && b) && c)"},
+		{"a < b && b <= c", "((a < b) && (b <= c))"},
+		{"a == b || !c", "((a == b) || (!c))"},
+		{"x >= 1 && x <= 10 || y", "(((x >= 1) && (x <= 10)) || y)"},
+		{"(a || b) && c", "((a || b) && c)"},
+		{"true && false", "(true && false)"},
+		{"a * [1, 2, 3, 4][b * c] * d", "((a * ([1, 2, 3, 4][(b * c)])) * d)"},
+		{"-a[0]", "(-(a[0]))"},
+		{"a[0][1] + b[i + 1]", "(((a[0])[1]) + (b[(i + 1)]))"},
+		{"[[1], []]", "[[1], []]"},
+		{"a + add(b * c) + d", "((a + add((b * c))) + d)"},
+		{"add(a, b, 1, 2 * 3, 4 + 5, add(6, 7 * 8))", "add(a, b, 1, (2 * 3), (4 + 5), add(6, (7 * 8)))"},
+		{"add(a + b + c * d / f + g)", "add((((a + b) + ((c * d) / f)) + g))"},
+		{"-f(x)", "(-f(x))"},
+		{"f(x)[0](y)", "(f(x)[0])(y)"},
+		{"a.b.c", "((a.b).c)"},
+		{"-a.b", "(-(a.b))"},
+		{"a.b[0].c(x)", "(((a.b)[0]).c)(x)"},
+		{"x = y + 1", "(x = (y + 1))"},
+		{"a = b = c", "(a = (b = c))"},
+		{"x += y || z", "(x += (y || z))"},
+		{"a[i] -= b * c", "((a[i]) -= (b * c))"},
+		{"a.b *= 2", "((a.b) *= 2)"},
+		{"x /= f(y)", "(x /= f(y))"},
+		{"a ?? b || c", "(a ?? (b || c))"},
+		{"a || b ?? c && d", "((a || b) ?? (c && d))"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"x = a ?? null", "(x = (a ?? null))"},
+		{"a?.b.c", "((a?.b).c)"},
+		{"a?.[0]?.b", "((a?.[0])?.b)"},
+		{"-a?.b + 1", "((-(a?.b)) + 1)"},
+		{"a?.b ?? f(x)?.[i + 1]", "((a?.b) ?? (f(x)?.[(i + 1)]))"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equalf(t, tt.expected, program.String(), "expected=%q, got=%q", tt.expected, program.String())
+	}
+}
+
+func TestNumericLiteralExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"5;", int64(5)},
+		{"1_000_000;", int64(1000000)},
+		{"0xff;", int64(255)},
+		{"0XfF;", int64(255)},
+		{"0o17;", int64(15)},
+		{"0b1010;", int64(10)},
+		{"017;", int64(17)},
+		{"9223372036854775807;", int64(9223372036854775807)},
+		{"3.14;", 3.14},
+		{"1e9;", 1e9},
+		{"2.5e-3;", 2.5e-3},
+		{"1_000.000_1;", 1000.0001},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		require.Truef(t, ok, "program.Statements[0] is not *ast.ExpressionStatement. got=%T", program.Statements[0])
+
+		switch expected := tt.expected.(type) {
+		case int64:
+			testIntegerLiteral(t, stmt.Expression, expected)
+		case float64:
+			fl, ok := stmt.Expression.(*ast.FloatLiteral)
+			require.Truef(t, ok, "exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+			assert.Equalf(t, expected, fl.Value, "fl.Value not %g. got=%g", expected, fl.Value)
+		}
+	}
+}
+
+func TestBigIntegerLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775808;", "9223372036854775808"},
+		{"0x1_0000_0000_0000_0000;", "18446744073709551616"},
+		{"0o1000000000000000000000;", "9223372036854775808"},
+		{"0b1" + strings.Repeat("0", 64) + ";", "18446744073709551616"},
+		{"123456789012345678901234567890;", "123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		testBigIntegerLiteral(t, stmt.Expression, tt.expected)
+	}
+}
+
+// TestIntegerLiteralsMatchMathBig is a property test which writes random
+// integers, both small and far larger than an int64, in every supported base
+// and checks that the parser decodes each one to the same value as math/big
+func TestIntegerLiteralsMatchMathBig(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	prefixes := map[int]string{2: "0b", 8: "0o", 10: "", 16: "0x"}
+
+	for i := 0; i < 1000; i++ {
+		n := new(big.Int).Rand(r, new(big.Int).Lsh(big.NewInt(1), uint(r.Intn(200))))
+
+		for base, prefix := range prefixes {
+			digits := n.Text(base)
+
+			// separate some of the digits with underscores
+			var literal strings.Builder
+			for j, ch := range digits {
+				if j > 0 && r.Intn(4) == 0 {
+					literal.WriteByte('_')
+				}
+				literal.WriteRune(ch)
+			}
+			input := prefix + literal.String()
+
+			p := New(lexer.New(input))
+			program := p.ParseProgram()
+			checkParserErrors(t, p)
+
+			lit, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.IntegerLiteral)
+			require.Truef(t, ok, "exp not *ast.IntegerLiteral for input %q", input)
+
+			got := lit.Big
+			if got == nil {
+				require.True(t, n.IsInt64(), "lit.Big is nil for %q, which does not fit in an int64", input)
+				got = big.NewInt(lit.Value)
+			} else {
+				require.False(t, n.IsInt64(), "lit.Big is set for %q, which fits in an int64", input)
+			}
+			require.Zerof(t, n.Cmp(got), "Invalid value for %q, expected %s, got %s", input, n, got)
+		}
+	}
+}
+
+func TestNumericLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"1e400;", []string{"line 1 column 1: float literal \"1e400\" overflows float64"}},
+		{"let x = 0x;", []string{"line 1 column 9: hexadecimal literal has no digits in \"0x\""}},
+		{"1 + 1__0;", []string{"line 1 column 5: '_' must separate successive digits in \"1__0\""}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		assert.Equalf(t, tt.expected, p.Errors(), "Invalid errors for input %q", tt.input)
+	}
+}
+
+func TestParsingArrayLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"[1, 2 * 2, 3 + 3]", []string{"1", "(2 * 2)", "(3 + 3)"}},
+		{"[]", []string{}},
+		{"[x]", []string{"x"}},
+		{"[1, 2,]", []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		array, ok := stmt.Expression.(*ast.ArrayLiteral)
+		require.Truef(t, ok, "exp not *ast.ArrayLiteral. got=%T", stmt.Expression)
+		require.Lenf(t, array.Elements, len(tt.expected), "len(array.Elements) not %d. got=%d", len(tt.expected), len(array.Elements))
+
+		for i, el := range array.Elements {
+			assert.Equalf(t, tt.expected[i], el.String(), "array.Elements[%d] not %s. got=%s", i, tt.expected[i], el)
+		}
+	}
+}
+
+func TestParsingIndexExpressions(t *testing.T) {
+	input := "myArray[1 + 1]"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	indexExp, ok := stmt.Expression.(*ast.IndexExpression)
+	require.Truef(t, ok, "exp not *ast.IndexExpression. got=%T", stmt.Expression)
+
+	assert.Equal(t, "myArray", indexExp.Left.String())
+	assert.Equal(t, "(1 + 1)", indexExp.Index.String())
+}
+
+func TestParsingArrayErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"[1, 2", []string{"line 1 column 5: expected next token to be ], got EOF instead"}},
+		{"a[1", []string{"line 1 column 3: expected next token to be ], got EOF instead"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		assert.Equalf(t, tt.expected, p.Errors(), "Invalid errors for input %q", tt.input)
+	}
+}
+
+func TestStringLiteralExpression(t *testing.T) {
+	input := `"hello world";`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	literal, ok := stmt.Expression.(*ast.StringLiteral)
+	require.Truef(t, ok, "exp not *ast.StringLiteral. got=%T", stmt.Expression)
+	assert.Equal(t, "hello world", literal.Value)
+}
+
+func TestStringLiteralStringRoundTrips(t *testing.T) {
+	// string literals have no escape sequences, so printing one must not add
+	// any, or the printed program would lex to a different value
+	tests := []string{
+		`"a\b"`,
+		"\"tab\there\"",
+		"\"line\nbreak\"",
+		`"\n"`,
+	}
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equal(t, input, program.String(), "Invalid String for input %q", input)
+	}
+}
+
+func TestParsingHashLiterals(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected [][2]string
+	}{
+		{`{"one": 1, "two": 2, "three": 3}`, [][2]string{{`"one"`, "1"}, {`"two"`, "2"}, {`"three"`, "3"}}},
+		{`{"name": "x", 1: true, false: [1]}`, [][2]string{{`"name"`, `"x"`}, {"1", "true"}, {"false", "[1]"}}},
+		{`{"one": 0 + 1, "two": 10 - 8,}`, [][2]string{{`"one"`, "(0 + 1)"}, {`"two"`, "(10 - 8)"}}},
+		{`{z: 1, a: 2, m: 3}`, [][2]string{{"z", "1"}, {"a", "2"}, {"m", "3"}}},
+		{`{"inner": {"k": "v"}}`, [][2]string{{`"inner"`, `{"k": "v"}`}}},
+		{`{}`, [][2]string{}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		hash, ok := stmt.Expression.(*ast.HashLiteral)
+		require.Truef(t, ok, "exp not *ast.HashLiteral. got=%T", stmt.Expression)
+		require.Lenf(t, hash.Pairs, len(tt.expected), "hash.Pairs has wrong length. got=%d", len(hash.Pairs))
+
+		// the pairs must be kept in the order they were written
+		for i, pair := range hash.Pairs {
+			assert.Equalf(t, tt.expected[i][0], pair.Key.String(), "hash.Pairs[%d].Key wrong", i)
+			assert.Equalf(t, tt.expected[i][1], pair.Value.String(), "hash.Pairs[%d].Value wrong", i)
+		}
+	}
+}
+
+func TestParsingHashLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`{"one" 1}`, []string{"line 1 column 8: expected next token to be :, got INT instead"}},
+		{`{"one": 1 "two": 2}`, []string{"line 1 column 11: expected next token to be ,, got STRING instead"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected[0], p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestThrowStatements(t *testing.T) {
+	input := `
+throw "oops";
+throw {"message": "oops", "kind": "ValueError"};
+throw err
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	expected := []string{
+		`throw "oops";`,
+		`throw {"message": "oops", "kind": "ValueError"};`,
+		"throw err;",
+	}
+
+	require.Lenf(t, program.Statements, len(expected), "program.Statements does not contain %d statements. got=%d", len(expected), len(program.Statements))
+	for i, stmt := range program.Statements {
+		throwStmt, ok := stmt.(*ast.ThrowStatement)
+		require.Truef(t, ok, "stmt not *ast.ThrowStatement. got=%T", stmt)
+		assert.Equal(t, "throw", throwStmt.TokenLiteral())
+		assert.Equal(t, expected[i], throwStmt.String())
+	}
+}
+
+func TestTryStatements(t *testing.T) {
+	tests := []struct {
+		input     string
+		block     string
+		parameter string
+		catch     string
+		finally   string
+	}{
+		{`try { risky; } catch (e) { e; } finally { cleanup; }`, "{ risky }", "e", "{ e }", "{ cleanup }"},
+		{`try { x } catch (err) { throw err; }`, "{ x }", "err", "{ throw err; }", ""},
+		{`try { let x = 1; x } finally { }`, "{ let x = 1;x }", "", "", "{  }"},
+		{`try { try { a } catch (e) { b } } catch (e) { c }`, "{ try { a } catch (e) { b } }", "e", "{ c }", ""},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.NotEmpty(t, program.Statements, "no statements for input %q", tt.input)
+		stmt, ok := program.Statements[0].(*ast.TryStatement)
+		require.Truef(t, ok, "stmt not *ast.TryStatement. got=%T", program.Statements[0])
+
+		assert.Equal(t, tt.block, stmt.Block.String())
+		if tt.catch == "" {
+			assert.Nil(t, stmt.Catch)
+			assert.Nil(t, stmt.CatchParameter)
+		} else {
+			require.NotNil(t, stmt.Catch)
+			assert.Equal(t, tt.parameter, stmt.CatchParameter.Value)
+			assert.Equal(t, tt.catch, stmt.Catch.String())
+		}
+		if tt.finally == "" {
+			assert.Nil(t, stmt.Finally)
+		} else {
+			require.NotNil(t, stmt.Finally)
+			assert.Equal(t, tt.finally, stmt.Finally.String())
+		}
+	}
+}
+
+func TestTryStatementErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`try { x }`, "line 1 column 1: expected catch or finally after try block"},
+		{`try { x } catch { y }`, "line 1 column 17: expected next token to be (, got { instead"},
+		{`try { x } catch () { y }`, "line 1 column 18: expected next token to be IDENT, got ) instead"},
+		{`try x`, "line 1 column 5: expected next token to be {, got IDENT instead"},
+		{`try { x `, "line 1 column 8: expected } to close the block opened at line 1 column 5"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestImportAndExportStatements(t *testing.T) {
+	input := `
+import "path/to/mod" as m;
+import "./helpers" as h
+export let x = 5;
+export let name: string = "monkey";
+`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Lenf(t, program.Statements, 4, "program.Statements does not contain 4 statements. got=%d", len(program.Statements))
+
+	imports := []struct {
+		path  string
+		alias string
+	}{
+		{"path/to/mod", "m"},
+		{"./helpers", "h"},
+	}
+	for i, tt := range imports {
+		stmt, ok := program.Statements[i].(*ast.ImportStatement)
+		require.Truef(t, ok, "stmt not *ast.ImportStatement. got=%T", program.Statements[i])
+		assert.Equal(t, tt.path, stmt.Path.Value)
+		assert.Equal(t, tt.alias, stmt.Alias.Value)
+	}
+
+	exports := []string{"x", "name"}
+	for i, name := range exports {
+		stmt, ok := program.Statements[i+2].(*ast.ExportStatement)
+		require.Truef(t, ok, "stmt not *ast.ExportStatement. got=%T", program.Statements[i+2])
+		assert.Equal(t, "export", stmt.TokenLiteral())
+		testLetStatement(t, stmt.Statement, name)
+	}
+
+	assert.Equal(t, `import "path/to/mod" as m;import "./helpers" as h;export let x = 5;export let name: string = "monkey";`, program.String())
+}
+
+func TestImportAndExportErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`import mod as m;`, "line 1 column 8: expected next token to be STRING, got IDENT instead"},
+		{`import "mod";`, "line 1 column 13: expected next token to be AS, got ; instead"},
+		{`import "mod" as "m";`, "line 1 column 17: expected next token to be IDENT, got STRING instead"},
+		{`export x = 5;`, "line 1 column 8: expected next token to be LET, got IDENT instead"},
+		{`try { import "mod" as m; } finally {}`, "line 1 column 7: import is only allowed at the top level of a module"},
+		{`try {} catch (e) { export let x = 1; }`, "line 1 column 20: export is only allowed at the top level of a module"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestCallExpressionParsing(t *testing.T) {
+	input := "add(1, 2 * 3, 4 + 5);"
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	exp, ok := stmt.Expression.(*ast.CallExpression)
+	require.Truef(t, ok, "stmt.Expression is not *ast.CallExpression. got=%T", stmt.Expression)
+
+	assert.Equal(t, "add", exp.Function.String())
+	require.Len(t, exp.Arguments, 3)
+	testIntegerLiteral(t, exp.Arguments[0], 1)
+	assert.Equal(t, "(2 * 3)", exp.Arguments[1].String())
+	assert.Equal(t, "(4 + 5)", exp.Arguments[2].String())
+}
+
+func TestFunctionLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input      string
+		parameters []string
+		types      []string
+		returnType string
+		expected   string
+	}{
+		{`fn(a: int, b: int) -> int { a + b }`, []string{"a", "b"}, []string{"int", "int"}, "int", "fn(a: int, b: int) -> int { (a + b) }"},
+		{`fn(x, y) { x * y; }`, []string{"x", "y"}, []string{"", ""}, "", "fn(x, y) { (x * y) }"},
+		{`fn(name: string, n) { name }`, []string{"name", "n"}, []string{"string", ""}, "", "fn(name: string, n) { name }"},
+		{`fn() -> bool { true }`, []string{}, []string{}, "bool", "fn() -> bool { true }"},
+		{`fn() {}`, []string{}, []string{}, "", "fn() {  }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		require.Truef(t, ok, "stmt.Expression is not *ast.FunctionLiteral. got=%T", stmt.Expression)
+
+		require.Len(t, function.Parameters, len(tt.parameters))
+		for i, name := range tt.parameters {
+			assert.Equal(t, name, function.Parameters[i].Name.Value)
+			if tt.types[i] == "" {
+				assert.Nil(t, function.Parameters[i].Type)
+			} else {
+				require.NotNil(t, function.Parameters[i].Type)
+				assert.Equal(t, tt.types[i], function.Parameters[i].Type.Name)
+			}
+		}
+		if tt.returnType == "" {
+			assert.Nil(t, function.ReturnType)
+		} else {
+			require.NotNil(t, function.ReturnType)
+			assert.Equal(t, tt.returnType, function.ReturnType.Name)
+		}
+		assert.Equal(t, tt.expected, function.String())
+	}
+}
+
+func TestFunctionLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`fn x { x }`, "line 1 column 4: expected next token to be (, got IDENT instead"},
+		{`fn(a:) { a }`, "line 1 column 6: expected next token to be IDENT, got ) instead"},
+		{`fn(a: int b) { a }`, "line 1 column 11: expected next token to be ), got IDENT instead"},
+		{`fn(a) -> { a }`, "line 1 column 10: expected next token to be IDENT, got { instead"},
+		{`fn(a) -> int a`, "line 1 column 14: expected next token to be {, got IDENT instead"},
+		{`while (x) { fn() { break; } }`, "line 1 column 20: break is not inside a loop"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestMacroLiteralParsing(t *testing.T) {
+	tests := []struct {
+		input      string
+		parameters []string
+		body       string
+	}{
+		{`macro(x, y) { x + y; }`, []string{"x", "y"}, "{ (x + y) }"},
+		{`macro() { quote(1) }`, []string{}, "{ quote(1) }"},
+		{`macro(cond, consequence) { quote(unquote(cond) && unquote(consequence)); }`, []string{"cond", "consequence"}, "{ quote((unquote(cond) && unquote(consequence))) }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		macro, ok := stmt.Expression.(*ast.MacroLiteral)
+		require.Truef(t, ok, "stmt.Expression is not *ast.MacroLiteral. got=%T", stmt.Expression)
+
+		require.Len(t, macro.Parameters, len(tt.parameters))
+		for i, name := range tt.parameters {
+			assert.Equal(t, name, macro.Parameters[i].Value)
+		}
+		assert.Equal(t, tt.body, macro.Body.String())
+	}
+}
+
+func TestMacroLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`macro x { x }`, "line 1 column 7: expected next token to be (, got IDENT instead"},
+		{`macro(1) { x }`, "line 1 column 7: expected next token to be IDENT, got INT instead"},
+		{`macro(x, ) { x }`, "line 1 column 10: expected next token to be IDENT, got ) instead"},
+		{`macro(x) x`, "line 1 column 10: expected next token to be {, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		target   string
+		operator string
+		value    string
+	}{
+		{`x = 5;`, "x", "=", "5"},
+		{`count += 1`, "count", "+=", "1"},
+		{`xs[0] -= 2;`, "(xs[0])", "-=", "2"},
+		{`config.size *= scale`, "(config.size)", "*=", "scale"},
+		{`total /= len(xs)`, "total", "/=", "len(xs)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		exp, ok := stmt.Expression.(*ast.AssignExpression)
+		require.Truef(t, ok, "stmt.Expression is not *ast.AssignExpression. got=%T", stmt.Expression)
+
+		assert.Equal(t, tt.target, exp.Target.String())
+		assert.Equal(t, tt.operator, exp.Operator)
+		assert.Equal(t, tt.value, exp.Value.String())
+	}
+}
+
+func TestAssignExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`1 = x;`, `line 1 column 3: invalid assignment target "1"`},
+		{`a + b = c;`, `line 1 column 7: invalid assignment target "+"`},
+		{`f(x) += 1;`, `line 1 column 6: invalid assignment target "("`},
+		{`-x = 1;`, `line 1 column 4: invalid assignment target "-"`},
+		{`(a = b) = c;`, `line 1 column 9: invalid assignment target "="`},
+		{`a?.b = 1;`, `line 1 column 6: invalid assignment target "?."`},
+		{`a?.[0] += 1;`, `line 1 column 8: invalid assignment target "?."`},
+		{`a.1 = 2;`, "line 1 column 3: expected next token to be IDENT, got INT instead"},
+		// targets which fail to parse are reported once, by the lexer
+		{`0x = 1`, `line 1 column 1: hexadecimal literal has no digits in "0x"`},
+		{`@ = 1`, `line 1 column 1: illegal character '@'`},
+		{`0x += 1`, `line 1 column 1: hexadecimal literal has no digits in "0x"`},
+		{`-@ = 1`, `line 1 column 2: illegal character '@'`},
+		{`!0x = 1`, `line 1 column 2: hexadecimal literal has no digits in "0x"`},
+		{`1 + @ = 2`, `line 1 column 5: illegal character '@'`},
+		{`f(@) = 1`, `line 1 column 3: illegal character '@'`},
+		{`(@) = 1`, `line 1 column 2: illegal character '@'`},
+		{`let x = @ = 1`, `line 1 column 9: illegal character '@'`},
+		{`match (x) { 1 => @ = 2 }`, `line 1 column 18: illegal character '@'`},
+		{`a?.[@] = 1`, `line 1 column 5: illegal character '@'`},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+		assert.NotPanics(t, func() { _ = program.String() }, "String panicked for input %q", tt.input)
+	}
+}
+
+func TestMatchExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match (n) { 0 => "zero", _ => "other" }`, `match (n) { 0 => "zero", _ => "other" }`},
+		{`match (n) { -1 => a, 2.5 => b, "s" => c, true => d, }`, `match (n) { (-1) => a, 2.5 => b, "s" => c, true => d }`},
+		{`match (n) { x if x > 0 => x, x => -x }`, "match (n) { x if (x > 0) => x, x => (-x) }"},
+		{`match (xs) { [] => 0, [x] => x, [x, y] => x + y, [x, _, ...rest] => rest }`, "match (xs) { [] => 0, [x] => x, [x, y] => (x + y), [x, _, ...rest] => rest }"},
+		{`match (event) { {type: "a", data} => data, {type: [1, x]} => x }`, `match (event) { {type: "a", data} => data, {type: [1, x]} => x }`},
+		{`match (x) { null => 0, n => n }`, "match (x) { null => 0, n => n }"},
+		{`match (f(x)) { }`, "match (f(x)) {  }"},
+		{`let y = match (x) { _ => 1 } + 1`, "let y = (match (x) { _ => 1 } + 1);"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equalf(t, tt.expected, program.String(), "expected=%q, got=%q", tt.expected, program.String())
+		assert.Empty(t, p.Warnings(), "unexpected warnings for input %q", tt.input)
+	}
+}
+
+func TestUnderscoreIsAnIdentifierInExpressions(t *testing.T) {
+	l := lexer.New("f(_); _ + 1; let _ = _;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Lenf(t, program.Statements, 3, "program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+	assert.Equal(t, "f(_)", program.Statements[0].String())
+	assert.Equal(t, "(_ + 1)", program.Statements[1].String())
+
+	let := program.Statements[2].(*ast.LetStatement)
+	_, ok := let.Name.(*ast.WildcardPattern)
+	assert.Truef(t, ok, "let.Name is not *ast.WildcardPattern. got=%T", let.Name)
+	_, ok = let.Value.(*ast.Identifier)
+	assert.Truef(t, ok, "let.Value is not *ast.Identifier. got=%T", let.Value)
+}
+
+func TestMatchExpressionErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`match x { _ => 1 }`, "line 1 column 7: expected next token to be (, got IDENT instead"},
+		{`match (x) _ => 1`, "line 1 column 11: expected next token to be {, got IDENT instead"},
+		{`match (x) { 1 + 1 => 2 }`, "line 1 column 15: expected next token to be =>, got + instead"},
+		{`match (x) { 1 -> 2 }`, "line 1 column 15: expected next token to be =>, got -> instead"},
+		{`match (x) { 1 => 2 3 => 4 }`, "line 1 column 20: expected next token to be ,, got INT instead"},
+		{`match (x) { -y => 2 }`, "line 1 column 13: expected a pattern, got - instead"},
+		{`match (x) { f(y) => 2 }`, "line 1 column 14: expected next token to be =>, got ( instead"},
+		{`match (x) { [1, ...rest, 2] => 2 }`, "line 1 column 17: a rest element must be the last part of a pattern"},
+		{`let 1 = x`, "line 1 column 5: expected an identifier, [ or { to begin a binding, got INT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestMatchExpressionWarnings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`match (ok) { true => 1, false => 0 }`, []string{}},
+		{`match (ok) { true => 1, _ => 0 }`, []string{}},
+		{`match (ok) { true => 1, other => 0 }`, []string{}},
+		{`match (n) { 1 => 1 }`, []string{}},
+		{`match (x) { true => 1, 0 => 0 }`, []string{}},
+		{`match (x) { true => 1, [y] => y }`, []string{}},
+		{`match (ok) { true => 1 }`, []string{"line 1 column 1: match is not exhaustive, false is not handled"}},
+		{`match (ok) { false => 0 }`, []string{"line 1 column 1: match is not exhaustive, true is not handled"}},
+		{`match (ok) { true if x => 1, false => 0 }`, []string{"line 1 column 1: match is not exhaustive, true is not handled"}},
+		{`match (ok) { true => 1, _ if x => 0 }`, []string{"line 1 column 1: match is not exhaustive, false is not handled"}},
+		{`let x = 1;
+let y = match (ok) { true if a => 1, false if b => 0 }`, []string{"line 2 column 9: match is not exhaustive, true or false is not handled"}},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+		checkParserErrors(t, p)
+
+		assert.Equalf(t, tt.expected, p.Warnings(), "Invalid warnings for input %q", tt.input)
+	}
+}
+
+func TestNullLiteralExpression(t *testing.T) {
+	l := lexer.New("null;")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	null, ok := stmt.Expression.(*ast.NullLiteral)
+	require.Truef(t, ok, "stmt.Expression is not *ast.NullLiteral. got=%T", stmt.Expression)
+	assert.Equal(t, "null", null.TokenLiteral())
+}
+
+func TestOptionalAccessExpressions(t *testing.T) {
+	l := lexer.New("config?.port; xs?.[1]; config.port")
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	require.Lenf(t, program.Statements, 3, "program.Statements does not contain 3 statements. got=%d", len(program.Statements))
+
+	chain, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.OptionalChain)
+	require.Truef(t, ok, "expression is not *ast.OptionalChain. got=%T", program.Statements[0])
+	field, ok := chain.Expression.(*ast.FieldExpression)
+	require.Truef(t, ok, "chain.Expression is not *ast.FieldExpression. got=%T", chain.Expression)
+	assert.True(t, field.Optional)
+	assert.Equal(t, "config", field.Object.String())
+	assert.Equal(t, "port", field.Field.Value)
+
+	chain, ok = program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.OptionalChain)
+	require.Truef(t, ok, "expression is not *ast.OptionalChain. got=%T", program.Statements[1])
+	index, ok := chain.Expression.(*ast.IndexExpression)
+	require.Truef(t, ok, "chain.Expression is not *ast.IndexExpression. got=%T", chain.Expression)
+	assert.True(t, index.Optional)
+	assert.Equal(t, "xs", index.Left.String())
+	testIntegerLiteral(t, index.Index, 1)
+
+	field, ok = program.Statements[2].(*ast.ExpressionStatement).Expression.(*ast.FieldExpression)
+	require.Truef(t, ok, "expression is not *ast.FieldExpression. got=%T", program.Statements[2])
+	assert.False(t, field.Optional)
+}
+
+func TestOptionalChainExtent(t *testing.T) {
+	tests := []struct {
+		input string
+		// chain is the part of the input inside the outermost optional
+		// chain, or empty when the outermost expression is not a chain
+		chain    string
+		expected string
+	}{
+		// every link after the ?. is skipped when a is null
+		{`a?.b.c`, "((a?.b).c)", "((a?.b).c)"},
+		{`a?.b.c(x)[0]`, "(((a?.b).c)(x)[0])", "(((a?.b).c)(x)[0])"},
+		{`a?.b?.c`, "((a?.b)?.c)", "((a?.b)?.c)"},
+		{`a.b?.[i].c`, "(((a.b)?.[i]).c)", "(((a.b)?.[i]).c)"},
+		// parentheses and other operators end the chain
+		{`(a?.b).c`, "", "((a?.b).c)"},
+		{`a?.b + c.d`, "", "((a?.b) + (c.d))"},
+		{`a?.b ?? c`, "", "((a?.b) ?? c)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		exp := program.Statements[0].(*ast.ExpressionStatement).Expression
+		assert.Equal(t, tt.expected, exp.String())
+
+		chain, ok := exp.(*ast.OptionalChain)
+		if tt.chain == "" {
+			assert.Falsef(t, ok, "expression is *ast.OptionalChain for input %q", tt.input)
+			continue
+		}
+		require.Truef(t, ok, "expression is not *ast.OptionalChain. got=%T", exp)
+		assert.Equal(t, tt.chain, chain.Expression.String())
+		_, nested := chain.Expression.(*ast.OptionalChain)
+		assert.Falsef(t, nested, "chain is nested for input %q", tt.input)
+	}
+}
+
+func TestOptionalAccessErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`a?.1`, "line 1 column 4: expected a field name or [ after ?., got INT instead"},
+		{`a?.(b)`, "line 1 column 4: expected a field name or [ after ?., got ( instead"},
+		{`a?.[0`, "line 1 column 5: expected next token to be ], got EOF instead"},
+		{`a ?? `, "line 1 column 5: no prefix parse function for EOF found"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestWhileStatements(t *testing.T) {
+	tests := []struct {
+		input     string
+		condition string
+		body      string
+	}{
+		{`while (x < 10) { x; }`, "(x < 10)", "{ x }"},
+		{`while (true) { break; }`, "true", "{ break; }"},
+		{`while (a && b) { while (c) { continue } }`, "(a && b)", "{ while (c) { continue; } }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.WhileStatement)
+		require.Truef(t, ok, "stmt not *ast.WhileStatement. got=%T", program.Statements[0])
+
+		assert.Equal(t, tt.condition, stmt.Condition.String())
+		assert.Equal(t, tt.body, stmt.Body.String())
+	}
+}
+
+func TestForInStatements(t *testing.T) {
+	tests := []struct {
+		input    string
+		variable string
+		iterable string
+		body     string
+	}{
+		{`for (x in [1, 2, 3]) { x; }`, "x", "[1, 2, 3]", "{ x }"},
+		{`for (key in {"a": 1}) { continue; }`, "key", `{"a": 1}`, "{ continue; }"},
+		{`for (i in 10) { if_odd(i); break }`, "i", "10", "{ if_odd(i)break; }"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		require.Lenf(t, program.Statements, 1, "program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		stmt, ok := program.Statements[0].(*ast.ForInStatement)
+		require.Truef(t, ok, "stmt not *ast.ForInStatement. got=%T", program.Statements[0])
+
+		assert.Equal(t, tt.variable, stmt.Variable.Value)
+		assert.Equal(t, tt.iterable, stmt.Iterable.String())
+		assert.Equal(t, tt.body, stmt.Body.String())
+	}
+}
+
+func TestLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`break;`, "line 1 column 1: break is not inside a loop"},
+		{`continue`, "line 1 column 1: continue is not inside a loop"},
+		{`try { break; } finally {}`, "line 1 column 7: break is not inside a loop"},
+		{`while (x) { macro() { break; } }`, "line 1 column 23: break is not inside a loop"},
+		{`while (x) {} continue;`, "line 1 column 14: continue is not inside a loop"},
+		{`while x { }`, "line 1 column 7: expected next token to be (, got IDENT instead"},
+		{`while (x) x`, "line 1 column 11: expected next token to be {, got IDENT instead"},
+		{`for (1 in x) {}`, "line 1 column 6: expected next token to be IDENT, got INT instead"},
+		{`for (x of y) {}`, "line 1 column 8: expected next token to be IN, got IDENT instead"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		p.ParseProgram()
+
+		require.NotEmpty(t, p.Errors(), "no errors for input %q", tt.input)
+		assert.Equalf(t, tt.expected, p.Errors()[0], "Invalid first error for input %q", tt.input)
+	}
+}
+
+func TestMalformedInputDoesNotPanic(t *testing.T) {
+	tests := []string{
+		`@`,
+		`@ + 1`,
+		`1 + @`,
+		`!@`,
+		`-0x`,
+		`0x && y`,
+		`a || @ || b`,
+		`(@)`,
+		`(@) + 1`,
+		`[@, 1]`,
+		`f(@)`,
+		`a[@]`,
+		`{@: 1}`,
+		`{1: @}`,
+		`let x = @;`,
+		`return @;`,
+		`throw @ + 1;`,
+		`let x = 1 + ;`,
+		`while (@) { 1 }`,
+		`for (x in @) { 1 }`,
+		`match (@) { _ => 1 }`,
+		`match (x) { _ => @ }`,
+		`match (x) { n if @ => 1 }`,
+	}
+
+	identity := func(node ast.Node) ast.Node { return node }
+
+	for _, input := range tests {
+		l := lexer.New(input)
+		p := New(l)
+		program := p.ParseProgram()
+
+		assert.NotEmpty(t, p.Errors(), "no errors for input %q", input)
+		for _, stmt := range program.Statements {
+			assert.NotNil(t, stmt, "nil statement for input %q", input)
+		}
+		assert.NotPanics(t, func() { _ = program.String() }, "String panicked for input %q", input)
+		assert.NotPanics(t, func() { ast.Modify(program, identity) }, "Modify panicked for input %q", input)
+	}
+}
+
+func testBigIntegerLiteral(t *testing.T, il ast.Expression, value string) bool {
+	integ, ok := il.(*ast.IntegerLiteral)
+	if !ok {
+		t.Errorf("il not *ast.IntegerLiteral. got=%T", il)
+		return false
+	}
+
+	if !assert.NotNil(t, integ.Big, "integ.Big is nil") {
+		return false
+	}
+
+	return assert.Equalf(t, value, integ.Big.String(), "integ.Big not %s. got=%s", value, integ.Big)
+}
+
+func testIntegerLiteral(t *testing.T, il ast.Expression, value int64) bool {
+	integ, ok := il.(*ast.IntegerLiteral)
+	if !ok {
+		t.Errorf("il not *ast.IntegerLiteral. got=%T", il)
+		return false
+	}
+
+	return assert.Equalf(t, value, integ.Value, "integ.Value not %d. got=%d", value, integ.Value)
+}
+
+// benchmarkInput is a multi-megabyte program used to measure the throughput
+// of the parser on large generated scripts
+var benchmarkInput = strings.Repeat(`let five = 5;
+let ten = 10;
+let result = five * ten + (five - ten) / 2;
+let ok = result >= 10 && result <= 100 || !ok;
+return result != 42;
+`, 30000)
+
+func BenchmarkParseProgram(b *testing.B) {
+	b.SetBytes(int64(len(benchmarkInput)))
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		p := New(lexer.New(benchmarkInput))
+		p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			b.Fatalf("parser had %d errors: %v", len(p.Errors()), p.Errors()[0])
+		}
+	}
+}
@@ -1,58 +1,188 @@
 package token
 
+//go:generate stringer -type=Type -linecomment
+
+// Type is an integer enumeration which allows us to distinguish between types
+// of tokens. Comparing integers is much cheaper than comparing strings, which
+// matters when lexing large generated scripts. The printable name of each
+// type is generated by stringer from the line comments of the constants below
+type Type int
+
 const (
 	// Lexer / Parser Control
-	ILLEGAL = "ILLEGAL" // ILLEGAL is an illegal token or character which we don't know about
-	EOF     = "EOF"     // EOF is the end of the file
+
+	// ILLEGAL is an illegal token or character which we don't know about
+	ILLEGAL Type = iota // ILLEGAL
+	// EOF is the end of the file
+	EOF // EOF
 
 	// Identifiers + literals
-	IDENT = "IDENT" // IDENT is an identifer such as add, foobar, x, y, ...
-	INT   = "INT"   // INT is an integer, such as 1343456
+
+	// IDENT is an identifer such as add, foobar, x, y, ...
+	IDENT // IDENT
+	// INT is an integer, such as 1343456, 0xff, 0o17 or 0b1010
+	INT // INT
+	// FLOAT is a floating point number, such as 3.14 or 1e9
+	FLOAT // FLOAT
+	// STRING is a double quoted string, such as "hello world"
+	STRING // STRING
 
 	// Operators
-	ASSIGN   = "="  // ASSIGN: Assignment operation / Equal sign
-	PLUS     = "+"  // PLUS: Plus sign
-	MINUS    = "-"  // MINUS: Minus sign / Hyphen
-	BANG     = "!"  // BANG: Bang / Exclamation Point
-	ASTERISK = "*"  // ASTERISK: Asterisk
-	SLASH    = "/"  // SLASH: Forward slash
-	LT       = "<"  // LT: Less Than
-	GT       = ">"  // GT: Greater Than
-	EQ       = "==" // EQ: Equal operator
-	NOT_EQ   = "!=" // NOT_EQ: Inequality operator
+
+	// ASSIGN: Assignment operation / Equal sign
+	ASSIGN // =
+	// PLUS_ASSIGN: Addition and assignment
+	PLUS_ASSIGN // +=
+	// MINUS_ASSIGN: Subtraction and assignment
+	MINUS_ASSIGN // -=
+	// ASTERISK_ASSIGN: Multiplication and assignment
+	ASTERISK_ASSIGN // *=
+	// SLASH_ASSIGN: Division and assignment
+	SLASH_ASSIGN // /=
+	// PLUS: Plus sign
+	PLUS // +
+	// MINUS: Minus sign / Hyphen
+	MINUS // -
+	// BANG: Bang / Exclamation Point
+	BANG // !
+	// ASTERISK: Asterisk
+	ASTERISK // *
+	// SLASH: Forward slash
+	SLASH // /
+	// LT: Less Than
+	LT // <
+	// GT: Greater Than
+	GT // >
+	// LT_EQ: Less Than or Equal
+	LT_EQ // <=
+	// GT_EQ: Greater Than or Equal
+	GT_EQ // >=
+	// EQ: Equal operator
+	EQ // ==
+	// NOT_EQ: Inequality operator
+	NOT_EQ // !=
+	// AND: Logical and
+	AND // &&
+	// OR: Logical or
+	OR // ||
+	// NULLISH: Null-coalescing operator
+	NULLISH // ??
+	// QUESTION_DOT: Optional field or index access
+	QUESTION_DOT // ?.
+	// ARROW: Return type annotation arrow
+	ARROW // ->
+	// FATARROW: Separates the pattern of a match arm from it's result
+	FATARROW // =>
 
 	// Delimiters
-	COMMA     = ","
-	SEMICOLON = ";"
-	LPAREN    = "("
-	RPAREN    = ")"
-	LBRACE    = "{"
-	RBRACE    = "}"
+	COMMA     // ,
+	COLON     // :
+	DOT       // .
+	SPREAD    // ...
+	SEMICOLON // ;
+	LPAREN    // (
+	RPAREN    // )
+	LBRACE    // {
+	RBRACE    // }
+	LBRACKET  // [
+	RBRACKET  // ]
 
 	// Keywords
-	FUNCTION = "FUNCTION"
-	LET      = "LET"
-	TRUE     = "TRUE"
-	FALSE    = "FALSE"
-	IF       = "IF"
-	ELSE     = "ELSE"
-	RETURN   = "RETURN"
+	FUNCTION // FUNCTION
+	LET      // LET
+	TRUE     // TRUE
+	FALSE    // FALSE
+	NULL     // NULL
+	IF       // IF
+	ELSE     // ELSE
+	RETURN   // RETURN
+	TRY      // TRY
+	CATCH    // CATCH
+	FINALLY  // FINALLY
+	THROW    // THROW
+	IMPORT   // IMPORT
+	EXPORT   // EXPORT
+	AS       // AS
+	MACRO    // MACRO
+	WHILE    // WHILE
+	FOR      // FOR
+	IN       // IN
+	BREAK    // BREAK
+	CONTINUE // CONTINUE
+	MATCH    // MATCH
 )
 
-var keywords = map[string]Type{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"if":     IF,
-	"else":   ELSE,
-	"return": RETURN,
+// Operator pairs an operator or delimiter token type with the literal text
+// which represents it in the source code
+type Operator struct {
+	Type    Type
+	Literal string
+}
+
+// Operators is the single definition list of every operator and delimiter in
+// the Monkey programming language. The lexer builds it's longest-match table
+// from this list, so adding a new operator only requires a new token type and
+// an entry here
+var Operators = []Operator{
+	{ASSIGN, "="},
+	{PLUS_ASSIGN, "+="},
+	{MINUS_ASSIGN, "-="},
+	{ASTERISK_ASSIGN, "*="},
+	{SLASH_ASSIGN, "/="},
+	{PLUS, "+"},
+	{MINUS, "-"},
+	{BANG, "!"},
+	{ASTERISK, "*"},
+	{SLASH, "/"},
+	{LT, "<"},
+	{GT, ">"},
+	{LT_EQ, "<="},
+	{GT_EQ, ">="},
+	{EQ, "=="},
+	{NOT_EQ, "!="},
+	{AND, "&&"},
+	{OR, "||"},
+	{NULLISH, "??"},
+	{QUESTION_DOT, "?."},
+	{ARROW, "->"},
+	{FATARROW, "=>"},
+	{COMMA, ","},
+	{COLON, ":"},
+	{DOT, "."},
+	{SPREAD, "..."},
+	{SEMICOLON, ";"},
+	{LPAREN, "("},
+	{RPAREN, ")"},
+	{LBRACE, "{"},
+	{RBRACE, "}"},
+	{LBRACKET, "["},
+	{RBRACKET, "]"},
 }
 
-// Type is a string which allows us to distinguish between types of tokens
-// Strings are easy to debug without a lot of boilerplate, though are not as
-// performant as something like an iota enum
-type Type string
+var keywords = map[string]Type{
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+	"if":       IF,
+	"else":     ELSE,
+	"return":   RETURN,
+	"try":      TRY,
+	"catch":    CATCH,
+	"finally":  FINALLY,
+	"throw":    THROW,
+	"import":   IMPORT,
+	"export":   EXPORT,
+	"as":       AS,
+	"macro":    MACRO,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
+	"match":    MATCH,
+}
 
 // Token represents an emitted token from the lexer containing both it's type
 // and the literal value of the token
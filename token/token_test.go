@@ -0,0 +1,28 @@
+package token
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeString(t *testing.T) {
+	tests := []struct {
+		tType    Type
+		expected string
+	}{
+		{ILLEGAL, "ILLEGAL"},
+		{EOF, "EOF"},
+		{IDENT, "IDENT"},
+		{ASSIGN, "="},
+		{NOT_EQ, "!="},
+		{RBRACE, "}"},
+		{FUNCTION, "FUNCTION"},
+		{RETURN, "RETURN"},
+		{Type(-1), "Type(-1)"},
+	}
+
+	for _, tt := range tests {
+		assert.Equalf(t, tt.expected, tt.tType.String(), "Invalid name for token type %d", int(tt.tType))
+	}
+}
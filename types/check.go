@@ -0,0 +1,634 @@
+package types
+
+import (
+	"fmt"
+
+	"github.com/kkirsche/monkey/ast"
+	"github.com/kkirsche/monkey/token"
+)
+
+// scheme is the type of a binding. A generalized binding, such as a function
+// which works with values of any type, lists the type variables which are
+// replaced by new variables each time the binding is used
+type scheme struct {
+	variables []*Variable
+	t         Type
+}
+
+// environment holds the type of each binding within a scope
+type environment struct {
+	store map[string]*scheme
+	outer *environment
+}
+
+func newEnvironment(outer *environment) *environment {
+	return &environment{store: map[string]*scheme{}, outer: outer}
+}
+
+func (e *environment) get(name string) (*scheme, bool) {
+	s, ok := e.store[name]
+	if !ok && e.outer != nil {
+		return e.outer.get(name)
+	}
+	return s, ok
+}
+
+func (e *environment) set(name string, s *scheme) {
+	e.store[name] = s
+}
+
+// freeVariables adds the unbound type variables of every binding in the
+// environment, other than those which are generalized, to vars
+func (e *environment) freeVariables(vars map[*Variable]bool) {
+	for env := e; env != nil; env = env.outer {
+		for _, s := range env.store {
+			free := map[*Variable]bool{}
+			freeVariables(s.t, free)
+			for _, v := range s.variables {
+				delete(free, v)
+			}
+			for v := range free {
+				vars[v] = true
+			}
+		}
+	}
+}
+
+// Checker infers the type of every expression of a program, and reports the
+// places where the program uses a value with the wrong type
+type Checker struct {
+	errors    []string
+	types     map[ast.Node]Type
+	variables int  // the number of type variables created so far
+	returns   Type // the return type of the function being checked, nil outside of functions
+}
+
+// New creates a new checker
+func New() *Checker {
+	return &Checker{types: map[ast.Node]Type{}}
+}
+
+// Errors is a getter method allowing clients to read the type errors found in
+// the programs which have been checked
+func (c *Checker) Errors() []string {
+	return c.errors
+}
+
+// TypeOf returns the inferred type of an expression, or of an identifier bound
+// by a pattern or parameter, from a program which has been checked. Nil is
+// returned for any other node. Type variables which are still unknown once
+// the program has been checked are left in the type
+func (c *Checker) TypeOf(node ast.Node) Type {
+	t, ok := c.types[node]
+	if !ok {
+		return nil
+	}
+	return resolve(t)
+}
+
+// Check infers the types of the program, recording any type errors. The
+// program is expected to have parsed without errors
+func (c *Checker) Check(program *ast.Program) {
+	env := newEnvironment(nil)
+
+	for _, stmt := range program.Statements {
+		c.checkStatement(stmt, env)
+	}
+}
+
+func (c *Checker) errorf(tok token.Token, format string, args ...interface{}) {
+	msg := fmt.Sprintf("line %d column %d: %s", tok.Line, tok.Column, fmt.Sprintf(format, args...))
+	c.errors = append(c.errors, msg)
+}
+
+// expect unifies the type which was found at the token with the type which
+// was required there, reporting an error if they differ
+func (c *Checker) expect(tok token.Token, want, got Type) {
+	err := unify(want, got)
+	if err == nil {
+		return
+	}
+
+	p := newPrinter()
+	switch err {
+	case errInfinite:
+		c.errorf(tok, "expected %s, got %s, which would be an infinite type", p.print(want), p.print(got))
+	default:
+		c.errorf(tok, "expected %s, got %s", p.print(want), p.print(got))
+	}
+}
+
+func (c *Checker) fresh() *Variable {
+	c.variables++
+	return &Variable{id: c.variables}
+}
+
+// instantiate returns the type of a binding with each of it's generalized
+// type variables replaced by a new variable
+func (c *Checker) instantiate(s *scheme) Type {
+	if len(s.variables) == 0 {
+		return s.t
+	}
+
+	vars := map[*Variable]Type{}
+	for _, v := range s.variables {
+		vars[v] = c.fresh()
+	}
+
+	var substitute func(t Type) Type
+	substitute = func(t Type) Type {
+		switch t := prune(t).(type) {
+		case *Variable:
+			if fresh, ok := vars[t]; ok {
+				return fresh
+			}
+			return t
+		case *Array:
+			return &Array{Element: substitute(t.Element)}
+		case *Hash:
+			return &Hash{Key: substitute(t.Key), Value: substitute(t.Value)}
+		case *Function:
+			params := make([]Type, len(t.Parameters))
+			for i, param := range t.Parameters {
+				params[i] = substitute(param)
+			}
+			return &Function{Parameters: params, Return: substitute(t.Return)}
+		default:
+			return t
+		}
+	}
+
+	return substitute(s.t)
+}
+
+// generalize creates the scheme of a binding of type t, in which each type
+// variable which is not used by the rest of the environment is generalized
+func generalize(t Type, env *environment) *scheme {
+	free := map[*Variable]bool{}
+	freeVariables(t, free)
+
+	bound := map[*Variable]bool{}
+	env.freeVariables(bound)
+
+	s := &scheme{t: t}
+	for v := range free {
+		if !bound[v] {
+			s.variables = append(s.variables, v)
+		}
+	}
+	return s
+}
+
+// annotation returns the type named by a type annotation
+func (c *Checker) annotation(te *ast.TypeExpr) Type {
+	if t, ok := basics[te.Name]; ok {
+		return t
+	}
+
+	c.errorf(te.Token, "unknown type %s", te.Name)
+	return c.fresh()
+}
+
+// checkStatement infers the types within the statement. The type of the value
+// of an expression statement is returned, and nil for any other statement
+func (c *Checker) checkStatement(stmt ast.Statement, env *environment) Type {
+	switch stmt := stmt.(type) {
+	case *ast.ExpressionStatement:
+		return c.checkExpression(stmt.Expression, env)
+	case *ast.LetStatement:
+		c.checkLetStatement(stmt, env)
+	case *ast.ExportStatement:
+		c.checkLetStatement(stmt.Statement, env)
+	case *ast.ReturnStatement:
+		value := c.checkExpression(stmt.ReturnValue, env)
+		if c.returns != nil && stmt.ReturnValue != nil {
+			c.expect(tokenOf(stmt.ReturnValue), c.returns, value)
+		}
+	case *ast.ImportStatement:
+		// imported modules are not checked, so the alias may be used as any
+		// type
+		v := c.fresh()
+		env.set(stmt.Alias.Value, &scheme{variables: []*Variable{v}, t: v})
+	case *ast.BlockStatement:
+		return c.checkBlockStatement(stmt, newEnvironment(env))
+	case *ast.ThrowStatement:
+		c.checkExpression(stmt.Value, env)
+	case *ast.TryStatement:
+		c.checkBlockStatement(stmt.Block, newEnvironment(env))
+		if stmt.Catch != nil {
+			catchEnv := newEnvironment(env)
+			if stmt.CatchParameter != nil {
+				c.bindIdentifier(stmt.CatchParameter, c.fresh(), catchEnv)
+			}
+			c.checkBlockStatement(stmt.Catch, catchEnv)
+		}
+		if stmt.Finally != nil {
+			c.checkBlockStatement(stmt.Finally, newEnvironment(env))
+		}
+	case *ast.WhileStatement:
+		condition := c.checkExpression(stmt.Condition, env)
+		c.expect(tokenOf(stmt.Condition), Bool, condition)
+		c.checkBlockStatement(stmt.Body, newEnvironment(env))
+	case *ast.ForInStatement:
+		c.checkForInStatement(stmt, env)
+	}
+
+	return nil
+}
+
+// checkBlockStatement infers the types within the block, and returns the type
+// of it's value, which is the value of it's final statement
+func (c *Checker) checkBlockStatement(block *ast.BlockStatement, env *environment) Type {
+	var value Type
+	for _, stmt := range block.Statements {
+		value = c.checkStatement(stmt, env)
+	}
+
+	if value == nil {
+		// the block ends with a statement which has no value, so it's
+		// value is null
+		return c.fresh()
+	}
+	return value
+}
+
+func (c *Checker) checkLetStatement(stmt *ast.LetStatement, env *environment) {
+	name, named := stmt.Name.(*ast.Identifier)
+	_, function := stmt.Value.(*ast.FunctionLiteral)
+
+	// a function may call itself, so it's name is bound before it's body
+	// is checked
+	var self Type
+	if named && function {
+		self = c.fresh()
+		env.set(name.Value, &scheme{t: self})
+	}
+
+	value := c.checkExpression(stmt.Value, env)
+	if stmt.Type != nil {
+		c.expect(tokenOf(stmt.Value), c.annotation(stmt.Type), value)
+	}
+
+	if self == nil {
+		c.bindPattern(stmt.Name, value, env)
+		return
+	}
+
+	// only functions are generalized, as any other binding may be assigned
+	// a new value, which must have the same type wherever the binding is
+	// used
+	c.expect(tokenOf(stmt.Value), self, value)
+	c.types[name] = value
+	delete(env.store, name.Value)
+	env.set(name.Value, generalize(value, env))
+}
+
+func (c *Checker) checkForInStatement(stmt *ast.ForInStatement, env *environment) {
+	iterable := c.checkExpression(stmt.Iterable, env)
+
+	// iterating over a hash produces it's keys
+	var element Type
+	if hash, ok := prune(iterable).(*Hash); ok {
+		element = hash.Key
+	} else {
+		element = c.fresh()
+		c.expect(tokenOf(stmt.Iterable), &Array{Element: element}, iterable)
+	}
+
+	bodyEnv := newEnvironment(env)
+	c.bindIdentifier(stmt.Variable, element, bodyEnv)
+	c.checkBlockStatement(stmt.Body, bodyEnv)
+}
+
+func (c *Checker) bindIdentifier(ident *ast.Identifier, t Type, env *environment) {
+	c.types[ident] = t
+	env.set(ident.Value, &scheme{t: t})
+}
+
+// bindPattern binds each identifier of the pattern to the type of the part of
+// a value of type t which it matches
+func (c *Checker) bindPattern(pattern ast.Pattern, t Type, env *environment) {
+	switch pattern := pattern.(type) {
+	case *ast.Identifier:
+		c.bindIdentifier(pattern, t, env)
+	case *ast.LiteralPattern:
+		value := c.checkExpression(pattern.Value, env)
+		c.expect(pattern.Token, t, value)
+	case *ast.ArrayPattern:
+		element := c.fresh()
+		array := &Array{Element: element}
+		c.expect(pattern.Token, array, t)
+		for _, el := range pattern.Elements {
+			if rest, ok := el.(*ast.RestElement); ok {
+				c.bindIdentifier(rest.Name, array, env)
+				continue
+			}
+			c.bindPattern(el, element, env)
+		}
+	case *ast.HashPattern:
+		value := c.fresh()
+		hash := &Hash{Key: String, Value: value}
+		c.expect(pattern.Token, hash, t)
+		for _, pair := range pattern.Pairs {
+			c.bindPattern(pair.Value, value, env)
+		}
+		if pattern.Rest != nil {
+			c.bindIdentifier(pattern.Rest.Name, hash, env)
+		}
+	}
+}
+
+// checkExpression infers and records the type of the expression
+func (c *Checker) checkExpression(exp ast.Expression, env *environment) Type {
+	if exp == nil {
+		return c.fresh()
+	}
+
+	t := c.inferExpression(exp, env)
+	c.types[exp] = t
+	return t
+}
+
+func (c *Checker) inferExpression(exp ast.Expression, env *environment) Type {
+	switch exp := exp.(type) {
+	case *ast.IntegerLiteral:
+		return Int
+	case *ast.FloatLiteral:
+		return Float
+	case *ast.StringLiteral:
+		return String
+	case *ast.Boolean:
+		return Bool
+	case *ast.NullLiteral:
+		// null may be used in place of a value of any type
+		return c.fresh()
+	case *ast.Identifier:
+		s, ok := env.get(exp.Value)
+		if !ok {
+			c.errorf(exp.Token, "%s is not defined", exp.Value)
+			return c.fresh()
+		}
+		return c.instantiate(s)
+	case *ast.PrefixExpression:
+		return c.inferPrefixExpression(exp, env)
+	case *ast.InfixExpression:
+		left := c.checkExpression(exp.Left, env)
+		right := c.checkExpression(exp.Right, env)
+		return c.inferOperator(exp.Token, exp.Operator, left, right)
+	case *ast.LogicalExpression:
+		left := c.checkExpression(exp.Left, env)
+		right := c.checkExpression(exp.Right, env)
+		if exp.Operator == "??" {
+			c.expect(tokenOf(exp.Right), left, right)
+			return left
+		}
+		c.expect(tokenOf(exp.Left), Bool, left)
+		c.expect(tokenOf(exp.Right), Bool, right)
+		return Bool
+	case *ast.ArrayLiteral:
+		element := c.fresh()
+		for _, el := range exp.Elements {
+			c.expect(tokenOf(el), element, c.checkExpression(el, env))
+		}
+		return &Array{Element: element}
+	case *ast.HashLiteral:
+		key, value := c.fresh(), c.fresh()
+		for _, pair := range exp.Pairs {
+			c.expect(tokenOf(pair.Key), key, c.checkExpression(pair.Key, env))
+			c.expect(tokenOf(pair.Value), value, c.checkExpression(pair.Value, env))
+		}
+		return &Hash{Key: key, Value: value}
+	case *ast.IndexExpression:
+		return c.inferIndexExpression(exp, env)
+	case *ast.FieldExpression:
+		object := c.checkExpression(exp.Object, env)
+		value := c.fresh()
+		c.expect(tokenOf(exp.Object), &Hash{Key: String, Value: value}, object)
+		return value
+	case *ast.OptionalChain:
+		// a chain which stops at null has the value null, which may be
+		// used as a value of any type
+		return c.checkExpression(exp.Expression, env)
+	case *ast.AssignExpression:
+		return c.inferAssignExpression(exp, env)
+	case *ast.MatchExpression:
+		return c.inferMatchExpression(exp, env)
+	case *ast.CallExpression:
+		return c.inferCallExpression(exp, env)
+	case *ast.FunctionLiteral:
+		return c.inferFunctionLiteral(exp, env)
+	}
+
+	// macros are expanded before the program is run, so their bodies are
+	// not checked
+	return c.fresh()
+}
+
+func (c *Checker) inferPrefixExpression(exp *ast.PrefixExpression, env *environment) Type {
+	right := c.checkExpression(exp.Right, env)
+
+	if exp.Operator == "!" {
+		c.expect(tokenOf(exp.Right), Bool, right)
+		return Bool
+	}
+
+	return c.numeric(exp.Token, exp.Operator, right, Int, Float)
+}
+
+// inferOperator returns the type of the value of an infix operator applied to
+// operands of the given types. Both operands must have the same type
+func (c *Checker) inferOperator(tok token.Token, operator string, left, right Type) Type {
+	c.expect(tok, left, right)
+
+	switch operator {
+	case "==", "!=":
+		return Bool
+	case "<", ">", "<=", ">=":
+		c.numeric(tok, operator, left, Int, Float, String)
+		return Bool
+	case "+":
+		return c.numeric(tok, operator, left, Int, Float, String)
+	default:
+		return c.numeric(tok, operator, left, Int, Float)
+	}
+}
+
+// numeric checks that the operand of the operator has one of the allowed basic
+// types, and returns it. An operand whose type is not yet known is taken to be
+// an integer
+func (c *Checker) numeric(tok token.Token, operator string, operand Type, allowed ...*Basic) Type {
+	operand = prune(operand)
+	if v, ok := operand.(*Variable); ok {
+		v.instance = Int
+		return Int
+	}
+
+	for _, b := range allowed {
+		if operand == b {
+			return operand
+		}
+	}
+
+	c.errorf(tok, "operator %s is not defined for %s", operator, operand)
+	return operand
+}
+
+func (c *Checker) inferIndexExpression(exp *ast.IndexExpression, env *environment) Type {
+	left := c.checkExpression(exp.Left, env)
+	index := c.checkExpression(exp.Index, env)
+
+	if hash, ok := prune(left).(*Hash); ok {
+		c.expect(tokenOf(exp.Index), hash.Key, index)
+		return hash.Value
+	}
+
+	element := c.fresh()
+	c.expect(tokenOf(exp.Left), &Array{Element: element}, left)
+	c.expect(tokenOf(exp.Index), Int, index)
+	return element
+}
+
+func (c *Checker) inferAssignExpression(exp *ast.AssignExpression, env *environment) Type {
+	target := c.checkExpression(exp.Target, env)
+	value := c.checkExpression(exp.Value, env)
+
+	if exp.Operator == "=" {
+		c.expect(tokenOf(exp.Value), target, value)
+		return target
+	}
+
+	// a compound assignment such as += applies the operator before it
+	// assigns the result
+	operator := exp.Operator[:len(exp.Operator)-1]
+	c.expect(exp.Token, target, c.inferOperator(exp.Token, operator, target, value))
+	return target
+}
+
+func (c *Checker) inferMatchExpression(exp *ast.MatchExpression, env *environment) Type {
+	subject := c.checkExpression(exp.Subject, env)
+	result := c.fresh()
+
+	for _, arm := range exp.Arms {
+		armEnv := newEnvironment(env)
+		c.bindPattern(arm.Pattern, subject, armEnv)
+		if arm.Guard != nil {
+			c.expect(tokenOf(arm.Guard), Bool, c.checkExpression(arm.Guard, armEnv))
+		}
+		c.expect(tokenOf(arm.Body), result, c.checkExpression(arm.Body, armEnv))
+	}
+
+	return result
+}
+
+func (c *Checker) inferCallExpression(exp *ast.CallExpression, env *environment) Type {
+	function := c.checkExpression(exp.Function, env)
+
+	args := make([]Type, len(exp.Arguments))
+	for i, arg := range exp.Arguments {
+		args[i] = c.checkExpression(arg, env)
+	}
+
+	switch fn := prune(function).(type) {
+	case *Function:
+		if len(fn.Parameters) != len(args) {
+			c.errorf(exp.Token, "wrong number of arguments: want=%d, got=%d", len(fn.Parameters), len(args))
+			return fn.Return
+		}
+		for i, arg := range exp.Arguments {
+			c.expect(tokenOf(arg), fn.Parameters[i], args[i])
+		}
+		return fn.Return
+	case *Variable:
+		ret := c.fresh()
+		c.expect(exp.Token, fn, &Function{Parameters: args, Return: ret})
+		return ret
+	default:
+		c.errorf(exp.Token, "cannot call %s, which is not a function", fn)
+		return c.fresh()
+	}
+}
+
+func (c *Checker) inferFunctionLiteral(fl *ast.FunctionLiteral, env *environment) Type {
+	fnEnv := newEnvironment(env)
+
+	params := make([]Type, len(fl.Parameters))
+	for i, param := range fl.Parameters {
+		if param.Type != nil {
+			params[i] = c.annotation(param.Type)
+		} else {
+			params[i] = c.fresh()
+		}
+		c.bindIdentifier(param.Name, params[i], fnEnv)
+	}
+
+	var ret Type
+	if fl.ReturnType != nil {
+		ret = c.annotation(fl.ReturnType)
+	} else {
+		ret = c.fresh()
+	}
+
+	outer := c.returns
+	c.returns = ret
+	defer func() { c.returns = outer }()
+
+	body := c.checkBlockStatement(fl.Body, fnEnv)
+
+	// the value of the final statement is returned, so it must have the
+	// return type as well
+	tok := fl.Body.Token
+	if n := len(fl.Body.Statements); n > 0 {
+		if stmt, ok := fl.Body.Statements[n-1].(*ast.ExpressionStatement); ok {
+			tok = tokenOf(stmt.Expression)
+		}
+	}
+	c.expect(tok, ret, body)
+
+	return &Function{Parameters: params, Return: ret}
+}
+
+// tokenOf returns the token which errors about the expression are reported at
+func tokenOf(exp ast.Expression) token.Token {
+	switch exp := exp.(type) {
+	case *ast.Identifier:
+		return exp.Token
+	case *ast.IntegerLiteral:
+		return exp.Token
+	case *ast.FloatLiteral:
+		return exp.Token
+	case *ast.StringLiteral:
+		return exp.Token
+	case *ast.Boolean:
+		return exp.Token
+	case *ast.NullLiteral:
+		return exp.Token
+	case *ast.PrefixExpression:
+		return exp.Token
+	case *ast.InfixExpression:
+		return tokenOf(exp.Left)
+	case *ast.LogicalExpression:
+		return tokenOf(exp.Left)
+	case *ast.ArrayLiteral:
+		return exp.Token
+	case *ast.HashLiteral:
+		return exp.Token
+	case *ast.IndexExpression:
+		return tokenOf(exp.Left)
+	case *ast.FieldExpression:
+		return tokenOf(exp.Object)
+	case *ast.OptionalChain:
+		return tokenOf(exp.Expression)
+	case *ast.AssignExpression:
+		return tokenOf(exp.Target)
+	case *ast.MatchExpression:
+		return exp.Token
+	case *ast.CallExpression:
+		return tokenOf(exp.Function)
+	case *ast.FunctionLiteral:
+		return exp.Token
+	case *ast.MacroLiteral:
+		return exp.Token
+	}
+
+	return token.Token{}
+}
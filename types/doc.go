@@ -0,0 +1,26 @@
+package types
+
+/*
+	Package types implements optional static type inference for the Monkey
+	programming language. Monkey is dynamically typed, but most programs only
+	ever use each binding with values of a single type, so the types of their
+	expressions can be inferred without any annotations, and mistakes such as
+	adding a string to an integer reported before anything runs.
+
+	Inference follows the Hindley-Milner algorithm. Each expression is given a
+	type, using type variables for the parts which are not yet known, and the
+	types which must be equal are unified. A function bound by a let statement
+	is generalized, so `let id = fn(x) { x };` may be called with an integer
+	and then with a string. Other bindings keep a single type, as they may be
+	reassigned.
+
+	Some of Monkey is looser than the type system, and is checked as follows:
+
+	* null may be used as a value of any type
+	* arithmetic on operands whose type is not yet known takes them to be
+	  integers, so `fn(a, b) { a + b }` is inferred as `fn(int, int) -> int`
+	* indexing a value whose type is not yet known takes it to be an array
+	* imported modules are not checked, and their bindings may be used as
+	  any type
+	* a function may call itself, but not a function bound after it
+*/
@@ -0,0 +1,278 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Type is the type of a Monkey value, as inferred by the Checker
+type Type interface {
+	// String prints the type in the same syntax as a type annotation, such
+	// as `int` or `fn(int, string) -> bool`. Type variables which are still
+	// unknown are named a, b, c and so on, in the order they appear
+	String() string
+	typeNode()
+}
+
+// Basic is a type which has no parts, such as int or string
+type Basic struct {
+	Name string
+}
+
+// The basic types. Each is a single value, so basic types may be compared
+// with ==
+var (
+	Int    = &Basic{Name: "int"}
+	Float  = &Basic{Name: "float"}
+	Bool   = &Basic{Name: "bool"}
+	String = &Basic{Name: "string"}
+)
+
+// basics maps the name used in a type annotation to the basic type
+var basics = map[string]*Basic{
+	Int.Name:    Int,
+	Float.Name:  Float,
+	Bool.Name:   Bool,
+	String.Name: String,
+}
+
+// Array is the type of an array, every element of which has the same type
+type Array struct {
+	Element Type
+}
+
+// Hash is the type of a hash, every key of which has the same type, and every
+// value of which has the same type
+type Hash struct {
+	Key   Type
+	Value Type
+}
+
+// Function is the type of a function
+type Function struct {
+	Parameters []Type
+	Return     Type
+}
+
+// Variable is a type which is not yet known. Once unification finds what the
+// variable stands for, it is bound to that type and behaves as though it were
+// that type
+type Variable struct {
+	id       int
+	instance Type // the type the variable is bound to, nil while unknown
+}
+
+// typeNode implements the Type interface
+func (b *Basic) typeNode() {}
+
+// typeNode implements the Type interface
+func (a *Array) typeNode() {}
+
+// typeNode implements the Type interface
+func (h *Hash) typeNode() {}
+
+// typeNode implements the Type interface
+func (f *Function) typeNode() {}
+
+// typeNode implements the Type interface
+func (v *Variable) typeNode() {}
+
+// String implements the Type interface
+func (b *Basic) String() string { return b.Name }
+
+// String implements the Type interface
+func (a *Array) String() string { return newPrinter().print(a) }
+
+// String implements the Type interface
+func (h *Hash) String() string { return newPrinter().print(h) }
+
+// String implements the Type interface
+func (f *Function) String() string { return newPrinter().print(f) }
+
+// String implements the Type interface
+func (v *Variable) String() string { return newPrinter().print(v) }
+
+// printer prints types, naming each unknown type variable the same way
+// everywhere it appears
+type printer struct {
+	names map[*Variable]string
+}
+
+func newPrinter() *printer {
+	return &printer{names: map[*Variable]string{}}
+}
+
+func (p *printer) print(t Type) string {
+	switch t := prune(t).(type) {
+	case *Basic:
+		return t.Name
+	case *Array:
+		return "[" + p.print(t.Element) + "]"
+	case *Hash:
+		return "{" + p.print(t.Key) + ": " + p.print(t.Value) + "}"
+	case *Function:
+		params := []string{}
+		for _, param := range t.Parameters {
+			params = append(params, p.print(param))
+		}
+		return "fn(" + strings.Join(params, ", ") + ") -> " + p.print(t.Return)
+	case *Variable:
+		name, ok := p.names[t]
+		if !ok {
+			name = variableName(len(p.names))
+			p.names[t] = name
+		}
+		return name
+	}
+
+	return ""
+}
+
+// variableName names the nth distinct type variable of a printed type
+func variableName(n int) string {
+	if n < 26 {
+		return string(rune('a' + n))
+	}
+	return fmt.Sprintf("t%d", n)
+}
+
+// prune follows a chain of bound type variables to the type at the end of it,
+// which is either a type other than a variable or an unbound variable. The
+// chain is shortened as it is followed, so it need only be followed once
+func prune(t Type) Type {
+	if v, ok := t.(*Variable); ok && v.instance != nil {
+		v.instance = prune(v.instance)
+		return v.instance
+	}
+	return t
+}
+
+// resolve returns the type with every bound type variable within it replaced
+// by the type it is bound to
+func resolve(t Type) Type {
+	switch t := prune(t).(type) {
+	case *Array:
+		return &Array{Element: resolve(t.Element)}
+	case *Hash:
+		return &Hash{Key: resolve(t.Key), Value: resolve(t.Value)}
+	case *Function:
+		params := make([]Type, len(t.Parameters))
+		for i, param := range t.Parameters {
+			params[i] = resolve(param)
+		}
+		return &Function{Parameters: params, Return: resolve(t.Return)}
+	default:
+		return t
+	}
+}
+
+// occurs reports whether the unbound type variable v appears within t
+func occurs(v *Variable, t Type) bool {
+	switch t := prune(t).(type) {
+	case *Variable:
+		return t == v
+	case *Array:
+		return occurs(v, t.Element)
+	case *Hash:
+		return occurs(v, t.Key) || occurs(v, t.Value)
+	case *Function:
+		for _, param := range t.Parameters {
+			if occurs(v, param) {
+				return true
+			}
+		}
+		return occurs(v, t.Return)
+	}
+
+	return false
+}
+
+// freeVariables adds each unbound type variable within t to vars
+func freeVariables(t Type, vars map[*Variable]bool) {
+	switch t := prune(t).(type) {
+	case *Variable:
+		vars[t] = true
+	case *Array:
+		freeVariables(t.Element, vars)
+	case *Hash:
+		freeVariables(t.Key, vars)
+		freeVariables(t.Value, vars)
+	case *Function:
+		for _, param := range t.Parameters {
+			freeVariables(param, vars)
+		}
+		freeVariables(t.Return, vars)
+	}
+}
+
+var (
+	// errMismatch is returned by unify when the two types differ
+	errMismatch = errors.New("types do not match")
+	// errInfinite is returned by unify when the two types could only be
+	// made equal by a type which contains itself, such as `[a]` and `a`
+	errInfinite = errors.New("infinite type")
+)
+
+// unify makes the two types equal by binding the type variables within them,
+// or returns an error if they cannot be made equal. A failed unification may
+// leave some variables bound
+func unify(a, b Type) error {
+	a, b = prune(a), prune(b)
+
+	if v, ok := a.(*Variable); ok {
+		return bind(v, b)
+	}
+	if v, ok := b.(*Variable); ok {
+		return bind(v, a)
+	}
+
+	switch a := a.(type) {
+	case *Basic:
+		if a != b {
+			return errMismatch
+		}
+		return nil
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok {
+			return errMismatch
+		}
+		return unify(a.Element, b.Element)
+	case *Hash:
+		b, ok := b.(*Hash)
+		if !ok {
+			return errMismatch
+		}
+		if err := unify(a.Key, b.Key); err != nil {
+			return err
+		}
+		return unify(a.Value, b.Value)
+	case *Function:
+		b, ok := b.(*Function)
+		if !ok || len(a.Parameters) != len(b.Parameters) {
+			return errMismatch
+		}
+		for i := range a.Parameters {
+			if err := unify(a.Parameters[i], b.Parameters[i]); err != nil {
+				return err
+			}
+		}
+		return unify(a.Return, b.Return)
+	}
+
+	return errMismatch
+}
+
+// bind binds the unbound type variable v to t
+func bind(v *Variable, t Type) error {
+	if v == t {
+		return nil
+	}
+	if occurs(v, t) {
+		return errInfinite
+	}
+
+	v.instance = t
+	return nil
+}
@@ -0,0 +1,192 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/kkirsche/monkey/ast"
+	"github.com/kkirsche/monkey/lexer"
+	"github.com/kkirsche/monkey/parser"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func parse(t *testing.T, input string) *ast.Program {
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	require.Empty(t, p.Errors(), "parser errors for input %q", input)
+	return program
+}
+
+// lastType returns the type of the final statement of the program, which
+// must be an expression statement
+func lastType(t *testing.T, c *Checker, program *ast.Program) Type {
+	require.NotEmpty(t, program.Statements)
+	stmt, ok := program.Statements[len(program.Statements)-1].(*ast.ExpressionStatement)
+	require.Truef(t, ok, "last statement not *ast.ExpressionStatement. got=%T", program.Statements[len(program.Statements)-1])
+	return c.TypeOf(stmt.Expression)
+}
+
+func TestInferredTypes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`5`, "int"},
+		{`99999999999999999999`, "int"},
+		{`1.5`, "float"},
+		{`"monkey"`, "string"},
+		{`true`, "bool"},
+		{`null`, "a"},
+		{`[1, 2, 3]`, "[int]"},
+		{`[]`, "[a]"},
+		{`{"one": 1, "two": 2}`, "{string: int}"},
+		{`-5`, "int"},
+		{`!true`, "bool"},
+		{`1 + 2 * 3`, "int"},
+		{`1.5 / 2.5`, "float"},
+		{`"mon" + "key"`, "string"},
+		{`1 < 2`, "bool"},
+		{`"a" == "b"`, "bool"},
+		{`true && false || true`, "bool"},
+		{`let x = null; x ?? "default"`, "string"},
+		{`fn(x) { x }`, "fn(a) -> a"},
+		{`fn(a, b) { a + b }`, "fn(int, int) -> int"},
+		{`fn(a: string, b) { a + b }`, "fn(string, string) -> string"},
+		{`fn(x) -> float { return x; }`, "fn(float) -> float"},
+		{`fn(x) { let y = x; }`, "fn(a) -> b"},
+		{`fn(f, x) { f(f(x)) }`, "fn(fn(a) -> a, a) -> a"},
+		{`fn(f, g) { fn(x) { g(f(x)) } }`, "fn(fn(a) -> b, fn(b) -> c) -> fn(a) -> c"},
+		{`let add = fn(a, b) { a + b }; add(1, 2)`, "int"},
+		{`let id = fn(x) { x }; id(1); id("a")`, "string"},
+		{`let id = fn(x) { x }; [id(1), id(2)]`, "[int]"},
+		{`let fact = fn(n) { match (n) { 0 => 1, _ => n * fact(n - 1) } }; fact`, "fn(int) -> int"},
+		{`let xs = [1, 2]; xs[0]`, "int"},
+		{`let h = {"a": true}; h["a"]`, "bool"},
+		{`let person = {"name": "monkey"}; person.name`, "string"},
+		{`let person = {"name": "monkey"}; person?.name`, "string"},
+		{`let [first, ...rest] = [1, 2, 3]; rest`, "[int]"},
+		{`let [first, ...rest] = [1, 2, 3]; first`, "int"},
+		{`let {name, ...others} = {"name": "a"}; others`, "{string: string}"},
+		{`let x = null; x = 5; x`, "int"},
+		{`let x = 1; x += 2`, "int"},
+		{`let xs = [1]; xs[0] = 2`, "int"},
+		{`match (1) { 0 => "zero", n if n > 0 => "positive", _ => "negative" }`, "string"},
+		{`match ([1, 2]) { [a, b] => a + b, _ => 0 }`, "int"},
+		{`import "./math" as math; math.add(1, 2)`, "a"},
+		{`export let two = 2; two`, "int"},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		c := New()
+		c.Check(program)
+
+		assert.Empty(t, c.Errors(), "type errors for input %q", tt.input)
+		actual := lastType(t, c, program)
+		if assert.NotNil(t, actual, "no type for input %q", tt.input) {
+			assert.Equal(t, tt.expected, actual.String(), "Invalid type for input %q", tt.input)
+		}
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{`1 + "a"`, []string{"line 1 column 3: expected int, got string"}},
+		{`true + true`, []string{"line 1 column 6: operator + is not defined for bool"}},
+		{`-"a"`, []string{"line 1 column 1: operator - is not defined for string"}},
+		{`!5`, []string{"line 1 column 2: expected bool, got int"}},
+		{`[1, "a"]`, []string{"line 1 column 5: expected int, got string"}},
+		{`{"a": 1, 2: 2}`, []string{"line 1 column 10: expected string, got int"}},
+		{`let f = fn(x) { x + 1 }; f("a")`, []string{"line 1 column 28: expected int, got string"}},
+		{`let f = fn(x) { x }; f(1, 2)`, []string{"line 1 column 23: wrong number of arguments: want=1, got=2"}},
+		{`5(1)`, []string{"line 1 column 2: cannot call int, which is not a function"}},
+		{`x`, []string{"line 1 column 1: x is not defined"}},
+		{`let x: string = 5;`, []string{"line 1 column 17: expected string, got int"}},
+		{`let x: number = 5;`, []string{"line 1 column 8: unknown type number"}},
+		{`fn(x) { x(x) }`, []string{"line 1 column 10: expected a, got fn(a) -> b, which would be an infinite type"}},
+		{`while (1) { 2 }`, []string{"line 1 column 8: expected bool, got int"}},
+		{`for (x in 5) { x }`, []string{"line 1 column 11: expected [a], got int"}},
+		{`let [a] = 5;`, []string{"line 1 column 5: expected [a], got int"}},
+		{`let {a} = [1];`, []string{"line 1 column 5: expected {string: a}, got [int]"}},
+		{`match (1) { "a" => 1, _ => 2 }`, []string{"line 1 column 13: expected int, got string"}},
+		{`match (1) { 1 => 1, _ => "a" }`, []string{"line 1 column 26: expected int, got string"}},
+		{`match (1) { n if n => 1 }`, []string{"line 1 column 18: expected bool, got int"}},
+		{`fn(x) -> string { return 1; }`, []string{"line 1 column 26: expected string, got int"}},
+		{`fn() -> string { 1 }`, []string{"line 1 column 18: expected string, got int"}},
+		{`let x = 1; x = "a"`, []string{"line 1 column 16: expected int, got string"}},
+		{`let xs = [1]; xs["a"]`, []string{"line 1 column 18: expected int, got string"}},
+		{`let n = 1; n.name`, []string{"line 1 column 12: expected {string: a}, got int"}},
+		{`let xs = []; xs[0] + 1; xs[0] + "a"`, []string{"line 1 column 31: expected int, got string"}},
+		{
+			`let id = fn(x) { x }; id(1) + id("a")`,
+			[]string{"line 1 column 29: expected int, got string"},
+		},
+		{
+			"let a = 1 + true;\nlet b = a - \"c\";",
+			[]string{"line 1 column 11: expected int, got bool", "line 2 column 11: expected int, got string"},
+		},
+	}
+
+	for _, tt := range tests {
+		program := parse(t, tt.input)
+		c := New()
+		c.Check(program)
+
+		assert.Equal(t, tt.expected, c.Errors(), "Invalid errors for input %q", tt.input)
+	}
+}
+
+func TestTypeOf(t *testing.T) {
+	input := `let add = fn(a, b) { a + b };
+let sum = add(1, 2);
+let [first] = ["monkey"];`
+
+	program := parse(t, input)
+	c := New()
+	c.Check(program)
+	require.Empty(t, c.Errors())
+
+	add := program.Statements[0].(*ast.LetStatement)
+	fn := add.Value.(*ast.FunctionLiteral)
+	assert.Equal(t, "fn(int, int) -> int", c.TypeOf(add.Name).String())
+	assert.Equal(t, "fn(int, int) -> int", c.TypeOf(fn).String())
+	assert.Equal(t, "int", c.TypeOf(fn.Parameters[0].Name).String())
+
+	sum := program.Statements[1].(*ast.LetStatement)
+	call := sum.Value.(*ast.CallExpression)
+	assert.Equal(t, "int", c.TypeOf(sum.Name).String())
+	assert.Equal(t, "fn(int, int) -> int", c.TypeOf(call.Function).String())
+	assert.Equal(t, "int", c.TypeOf(call.Arguments[0]).String())
+
+	destructure := program.Statements[2].(*ast.LetStatement)
+	first := destructure.Name.(*ast.ArrayPattern).Elements[0]
+	assert.Equal(t, "string", c.TypeOf(first).String())
+	assert.Equal(t, "[string]", c.TypeOf(destructure.Value).String())
+
+	// statements have no type
+	assert.Nil(t, c.TypeOf(add))
+}
+
+func TestTypeString(t *testing.T) {
+	a, b := &Variable{id: 1}, &Variable{id: 2}
+	bound := &Variable{id: 3, instance: Int}
+
+	tests := []struct {
+		t        Type
+		expected string
+	}{
+		{Int, "int"},
+		{&Array{Element: &Array{Element: String}}, "[[string]]"},
+		{&Hash{Key: String, Value: bound}, "{string: int}"},
+		{&Function{Parameters: []Type{}, Return: Bool}, "fn() -> bool"},
+		{&Function{Parameters: []Type{b, a}, Return: b}, "fn(a, b) -> a"},
+		{&Function{Parameters: []Type{&Function{Parameters: []Type{a}, Return: Float}}, Return: a}, "fn(fn(a) -> float) -> a"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.t.String())
+	}
+}